@@ -4,44 +4,134 @@ import "github.com/jordanwebster/golox/token"
 
 type Expr interface {
 	Accept(visitor ExprVisitor) (interface{}, error)
+	Span() token.Span
+	SetSpan(span token.Span)
 }
 
 type BinaryExpr struct {
+	Node
 	Operator token.Token
 	Left     Expr
 	Right    Expr
 }
 
 type GroupingExpr struct {
+	Node
 	Expression Expr
 }
 
 type LiteralExpr struct {
+	Node
 	Value interface{}
 }
 
 type UnaryExpr struct {
+	Node
 	Operator token.Token
 	Right    Expr
 }
 
 type VariableExpr struct {
+	Node
 	Name token.Token
 }
 
 type AssignExpr struct {
+	Node
 	Name  token.Token
 	Value Expr
 }
 
 type LogicalExpr struct {
+	Node
 	Operator token.Token
 	Left     Expr
 	Right    Expr
 }
 
 type CallExpr struct {
+	Node
 	Callee    Expr
 	Paren     token.Token
 	Arguments []Expr
 }
+
+type GetExpr struct {
+	Node
+	Object Expr
+	Name   token.Token
+}
+
+type SetExpr struct {
+	Node
+	Object Expr
+	Name   token.Token
+	Value  Expr
+}
+
+type ThisExpr struct {
+	Node
+	Keyword token.Token
+}
+
+type ListExpr struct {
+	Node
+	Bracket  token.Token
+	Elements []Expr
+}
+
+// MapExpr is a map literal. Keys and Values are parallel, in source order.
+type MapExpr struct {
+	Node
+	Brace  token.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+// InterpolationExpr is a string literal with embedded expressions, such as
+// "a ${b} c". Each part is converted to a string as print would and the
+// results are concatenated.
+type InterpolationExpr struct {
+	Node
+	Parts []Expr
+}
+
+type IndexExpr struct {
+	Node
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+}
+
+type IndexSetExpr struct {
+	Node
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+	Value   Expr
+}
+
+// SliceExpr is xs[start:end]. Either bound may be nil when omitted.
+type SliceExpr struct {
+	Node
+	Object  Expr
+	Bracket token.Token
+	Start   Expr
+	End     Expr
+}
+
+// FunctionExpr is an anonymous function, written either as fun (a) { ... }
+// or as (a) => expr. The body of the arrow form is a single return
+// statement. Declaration is named "lambda" and is never executed as a
+// statement of its own.
+type FunctionExpr struct {
+	Node
+	Keyword     token.Token
+	Declaration *FunctionStmt
+}
+
+type SuperExpr struct {
+	Node
+	Keyword token.Token
+	Method  token.Token
+}
@@ -9,6 +9,17 @@ type ExprVisitor interface {
 	VisitAssignExpr(expr *AssignExpr) (interface{}, error)
 	VisitLogicalExpr(expr *LogicalExpr) (interface{}, error)
 	VisitCallExpr(expr *CallExpr) (interface{}, error)
+	VisitGetExpr(expr *GetExpr) (interface{}, error)
+	VisitSetExpr(expr *SetExpr) (interface{}, error)
+	VisitThisExpr(expr *ThisExpr) (interface{}, error)
+	VisitListExpr(expr *ListExpr) (interface{}, error)
+	VisitMapExpr(expr *MapExpr) (interface{}, error)
+	VisitInterpolationExpr(expr *InterpolationExpr) (interface{}, error)
+	VisitIndexExpr(expr *IndexExpr) (interface{}, error)
+	VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, error)
+	VisitSliceExpr(expr *SliceExpr) (interface{}, error)
+	VisitFunctionExpr(expr *FunctionExpr) (interface{}, error)
+	VisitSuperExpr(expr *SuperExpr) (interface{}, error)
 }
 
 func (expr *BinaryExpr) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -35,3 +46,36 @@ func (expr *LogicalExpr) Accept(visitor ExprVisitor) (interface{}, error) {
 func (expr *CallExpr) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitCallExpr(expr)
 }
+func (expr *GetExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitGetExpr(expr)
+}
+func (expr *SetExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSetExpr(expr)
+}
+func (expr *ThisExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitThisExpr(expr)
+}
+func (expr *ListExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitListExpr(expr)
+}
+func (expr *MapExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitMapExpr(expr)
+}
+func (expr *InterpolationExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitInterpolationExpr(expr)
+}
+func (expr *IndexExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexExpr(expr)
+}
+func (expr *IndexSetExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexSetExpr(expr)
+}
+func (expr *SliceExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSliceExpr(expr)
+}
+func (expr *FunctionExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitFunctionExpr(expr)
+}
+func (expr *SuperExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSuperExpr(expr)
+}
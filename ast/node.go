@@ -0,0 +1,18 @@
+package ast
+
+import "github.com/jordanwebster/golox/token"
+
+// Node is embedded in every expression and statement to record the range of
+// source it was parsed from. It lives apart from expr.go and stmt.go so that
+// the visitor generator does not treat it as a node type of its own.
+type Node struct {
+	span token.Span
+}
+
+func (node *Node) Span() token.Span {
+	return node.span
+}
+
+func (node *Node) SetSpan(span token.Span) {
+	node.span = span
+}
@@ -4,43 +4,102 @@ import "github.com/jordanwebster/golox/token"
 
 type Stmt interface {
 	Accept(visitor StmtVisitor) error
+	Span() token.Span
+	SetSpan(span token.Span)
 }
 
 type ExprStmt struct {
+	Node
 	Expression Expr
 }
 
 type PrintStmt struct {
+	Node
 	Expression Expr
 }
 
 type VarStmt struct {
+	Node
 	Name        token.Token
 	Initializer Expr
 }
 
 type BlockStmt struct {
+	Node
 	Statements []Stmt
 }
 
 type IfStmt struct {
+	Node
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
 }
 
+// WhileStmt also represents desugared for loops, in which case Increment
+// holds the loop's increment clause. It runs after every iteration,
+// including those ended early by continue.
 type WhileStmt struct {
+	Node
 	Condition Expr
 	Body      Stmt
+	Increment Expr
+}
+
+type BreakStmt struct {
+	Node
+	Keyword token.Token
+}
+
+type ContinueStmt struct {
+	Node
+	Keyword token.Token
+}
+
+type ThrowStmt struct {
+	Node
+	Keyword token.Token
+	Value   Expr
+}
+
+// TryStmt has at least one of a catch or a finally clause. CatchBody and
+// FinallyBody are nil if the clause is absent.
+type TryStmt struct {
+	Node
+	Keyword     token.Token
+	Body        []Stmt
+	CatchName   token.Token
+	CatchBody   []Stmt
+	FinallyBody []Stmt
 }
 
 type FunctionStmt struct {
+	Node
 	Name       token.Token
 	Parameters []token.Token
 	Body       []Stmt
 }
 
 type ReturnStmt struct {
+	Node
 	Keyword token.Token
 	Value   Expr
 }
+
+type ClassStmt struct {
+	Node
+	Name       token.Token
+	Superclass *VariableExpr
+	Methods    []*FunctionStmt
+}
+
+// ImportStmt is either "import path as Alias;", which binds the module
+// itself, or "from path import a, b;", which binds the listed exports.
+// Names is nil in the first form.
+type ImportStmt struct {
+	Node
+	Keyword token.Token
+	Path    token.Token
+	Alias   token.Token
+	Names   []token.Token
+}
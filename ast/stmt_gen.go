@@ -7,8 +7,14 @@ type StmtVisitor interface {
 	VisitBlockStmt(stmt *BlockStmt) error
 	VisitIfStmt(stmt *IfStmt) error
 	VisitWhileStmt(stmt *WhileStmt) error
+	VisitBreakStmt(stmt *BreakStmt) error
+	VisitContinueStmt(stmt *ContinueStmt) error
+	VisitThrowStmt(stmt *ThrowStmt) error
+	VisitTryStmt(stmt *TryStmt) error
 	VisitFunctionStmt(stmt *FunctionStmt) error
 	VisitReturnStmt(stmt *ReturnStmt) error
+	VisitClassStmt(stmt *ClassStmt) error
+	VisitImportStmt(stmt *ImportStmt) error
 }
 
 func (stmt *ExprStmt) Accept(visitor StmtVisitor) error {
@@ -29,9 +35,27 @@ func (stmt *IfStmt) Accept(visitor StmtVisitor) error {
 func (stmt *WhileStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitWhileStmt(stmt)
 }
+func (stmt *BreakStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBreakStmt(stmt)
+}
+func (stmt *ContinueStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitContinueStmt(stmt)
+}
+func (stmt *ThrowStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitThrowStmt(stmt)
+}
+func (stmt *TryStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitTryStmt(stmt)
+}
 func (stmt *FunctionStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitFunctionStmt(stmt)
 }
 func (stmt *ReturnStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitReturnStmt(stmt)
 }
+func (stmt *ClassStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitClassStmt(stmt)
+}
+func (stmt *ImportStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitImportStmt(stmt)
+}
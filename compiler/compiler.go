@@ -0,0 +1,952 @@
+package compiler
+
+import (
+	"math"
+
+	"github.com/jordanwebster/golox/ast"
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+	"github.com/jordanwebster/golox/vm"
+)
+
+const (
+	localsMax   = math.MaxUint8 + 1
+	upvaluesMax = math.MaxUint8 + 1
+)
+
+type functionType int
+
+const (
+	functionTypeScript functionType = iota
+	functionTypeFunction
+	functionTypeInitializer
+	functionTypeMethod
+)
+
+type local struct {
+	name string
+	// Scope depth of the declaration, or -1 while the initializer is being
+	// compiled.
+	depth      int
+	isCaptured bool
+}
+
+type upvalue struct {
+	index   uint8
+	isLocal bool
+}
+
+// loopCompiler tracks the jumps out of a loop body emitted by break and
+// continue, which are patched once the loop's layout is known.
+type loopCompiler struct {
+	enclosing *loopCompiler
+	// Scope depth outside the body. Locals deeper than this are discarded
+	// before jumping out.
+	scopeDepth int
+	// Number of try blocks outside the loop. Those nested inside it are
+	// exited before jumping out.
+	tryDepth      int
+	breakJumps    []int
+	continueJumps []int
+}
+
+// tryCompiler is a try block, or a catch clause followed by a finally
+// clause, whose handler is active while its body is compiled. Returns and
+// loop control that leave it must remove the handler and run the finally
+// clause on the way out.
+type tryCompiler struct {
+	finally []ast.Stmt
+	// The loop enclosing the try statement, which is the one that break and
+	// continue in the finally clause refer to.
+	loop *loopCompiler
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// Compiler lowers a resolved syntax tree into bytecode. One Compiler exists
+// per function being compiled, linked to the function that encloses it.
+type Compiler struct {
+	enclosing   *Compiler
+	function    *vm.ObjFunction
+	kind        functionType
+	locals      []local
+	upvalues    []upvalue
+	scopeDepth  int
+	class       *classCompiler
+	loop        *loopCompiler
+	tries       []tryCompiler
+	diagnostics *loxerror.Diagnostics
+	hadError    bool
+	// current is the token the instructions being emitted are attributed to
+	// in runtime errors.
+	current token.Token
+}
+
+func newCompiler(enclosing *Compiler, kind functionType, name string) *Compiler {
+	compiler := &Compiler{
+		enclosing: enclosing,
+		function:  vm.NewFunction(name),
+		kind:      kind,
+		locals:    make([]local, 0, 8),
+	}
+
+	if enclosing != nil {
+		compiler.class = enclosing.class
+		compiler.current = enclosing.current
+		compiler.diagnostics = enclosing.diagnostics
+	}
+
+	// Slot zero holds the function being called, or the receiver in methods.
+	slotZero := ""
+	if kind == functionTypeMethod || kind == functionTypeInitializer {
+		slotZero = "this"
+	}
+	compiler.locals = append(compiler.locals, local{name: slotZero, depth: 0})
+
+	return compiler
+}
+
+// Compile turns a list of statements into the function for a top-level
+// script, ready to be run by the VM. It returns nil if any errors were
+// reported.
+func Compile(statements []ast.Stmt, diagnostics *loxerror.Diagnostics) *vm.ObjFunction {
+	compiler := newCompiler(nil, functionTypeScript, "")
+	compiler.diagnostics = diagnostics
+	for _, stmt := range statements {
+		compiler.compileStmt(stmt)
+	}
+
+	function := compiler.end()
+	if compiler.hadError {
+		return nil
+	}
+
+	return function
+}
+
+func (compiler *Compiler) VisitExprStmt(stmt *ast.ExprStmt) error {
+	compiler.compileExpr(stmt.Expression)
+	compiler.emitOp(vm.OpPop)
+	return nil
+}
+
+func (compiler *Compiler) VisitPrintStmt(stmt *ast.PrintStmt) error {
+	compiler.compileExpr(stmt.Expression)
+	compiler.emitOp(vm.OpPrint)
+	return nil
+}
+
+func (compiler *Compiler) VisitVarStmt(stmt *ast.VarStmt) error {
+	compiler.current = stmt.Name
+	compiler.declareVariable(stmt.Name)
+
+	if stmt.Initializer != nil {
+		compiler.compileExpr(stmt.Initializer)
+	} else {
+		compiler.emitOp(vm.OpNil)
+	}
+
+	compiler.defineVariable(stmt.Name)
+	return nil
+}
+
+// VisitImportStmt binds each imported name as though by a var declaration.
+// Modules are cached by the VM so importing the same one again for each
+// name in a from import only runs it once.
+func (compiler *Compiler) VisitImportStmt(stmt *ast.ImportStmt) error {
+	path := compiler.makeConstant(vm.StringValue(stmt.Path.Literal.(string)))
+
+	if stmt.Names == nil {
+		compiler.declareVariable(stmt.Alias)
+		compiler.current = stmt.Path
+		compiler.emitOpShort(vm.OpImport, path)
+		compiler.defineVariable(stmt.Alias)
+		return nil
+	}
+
+	for _, name := range stmt.Names {
+		compiler.declareVariable(name)
+		compiler.current = stmt.Path
+		compiler.emitOpShort(vm.OpImport, path)
+		compiler.current = name
+		compiler.emitOpShort(vm.OpGetProperty, compiler.identifierConstant(name.Lexeme))
+		compiler.defineVariable(name)
+	}
+	return nil
+}
+
+func (compiler *Compiler) VisitBlockStmt(stmt *ast.BlockStmt) error {
+	compiler.compileBlock(stmt.Statements)
+	return nil
+}
+
+func (compiler *Compiler) VisitIfStmt(stmt *ast.IfStmt) error {
+	compiler.compileExpr(stmt.Condition)
+
+	thenJump := compiler.emitJump(vm.OpJumpIfFalse)
+	compiler.emitOp(vm.OpPop)
+	compiler.compileStmt(stmt.ThenBranch)
+
+	elseJump := compiler.emitJump(vm.OpJump)
+	compiler.patchJump(thenJump)
+	compiler.emitOp(vm.OpPop)
+
+	if stmt.ElseBranch != nil {
+		compiler.compileStmt(stmt.ElseBranch)
+	}
+	compiler.patchJump(elseJump)
+
+	return nil
+}
+
+func (compiler *Compiler) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	loopStart := len(compiler.chunk().Code)
+	compiler.compileExpr(stmt.Condition)
+
+	exitJump := compiler.emitJump(vm.OpJumpIfFalse)
+	compiler.emitOp(vm.OpPop)
+
+	loop := &loopCompiler{
+		enclosing:  compiler.loop,
+		scopeDepth: compiler.scopeDepth,
+		tryDepth:   len(compiler.tries),
+	}
+	compiler.loop = loop
+	compiler.compileStmt(stmt.Body)
+	compiler.loop = loop.enclosing
+
+	for _, jump := range loop.continueJumps {
+		compiler.patchJump(jump)
+	}
+	if stmt.Increment != nil {
+		compiler.compileExpr(stmt.Increment)
+		compiler.emitOp(vm.OpPop)
+	}
+	compiler.emitLoop(loopStart)
+
+	compiler.patchJump(exitJump)
+	compiler.emitOp(vm.OpPop)
+
+	// The condition has already been popped when breaking out of the body.
+	for _, jump := range loop.breakJumps {
+		compiler.patchJump(jump)
+	}
+	return nil
+}
+
+func (compiler *Compiler) VisitBreakStmt(stmt *ast.BreakStmt) error {
+	compiler.current = stmt.Keyword
+	compiler.exitTries(compiler.loop.tryDepth)
+	compiler.popLocals(compiler.loop.scopeDepth)
+	compiler.loop.breakJumps = append(compiler.loop.breakJumps, compiler.emitJump(vm.OpJump))
+	return nil
+}
+
+func (compiler *Compiler) VisitContinueStmt(stmt *ast.ContinueStmt) error {
+	compiler.current = stmt.Keyword
+	compiler.exitTries(compiler.loop.tryDepth)
+	compiler.popLocals(compiler.loop.scopeDepth)
+	compiler.loop.continueJumps = append(compiler.loop.continueJumps, compiler.emitJump(vm.OpJump))
+	return nil
+}
+
+func (compiler *Compiler) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	compiler.current = stmt.Name
+	compiler.declareVariable(stmt.Name)
+	// Mark the function initialized straight away so that it can refer to
+	// itself recursively.
+	compiler.markInitialized()
+	compiler.compileFunction(stmt, functionTypeFunction)
+	compiler.defineVariable(stmt.Name)
+	return nil
+}
+
+func (compiler *Compiler) VisitFunctionExpr(expr *ast.FunctionExpr) (interface{}, error) {
+	compiler.current = expr.Keyword
+	compiler.compileFunction(expr.Declaration, functionTypeFunction)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	compiler.current = stmt.Keyword
+	if stmt.Value == nil {
+		compiler.emitDefaultResult()
+	} else {
+		compiler.compileExpr(stmt.Value)
+	}
+
+	if len(compiler.tries) > 0 {
+		// The result waits in a slot of its own while the finally clauses
+		// run.
+		compiler.addTemporary()
+		compiler.exitTries(0)
+		compiler.current = stmt.Keyword
+		compiler.emitOp(vm.OpReturn)
+		compiler.dropTemporary()
+		return nil
+	}
+
+	compiler.emitOp(vm.OpReturn)
+	return nil
+}
+
+func (compiler *Compiler) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	compiler.compileExpr(stmt.Value)
+	compiler.current = stmt.Keyword
+	compiler.emitOp(vm.OpThrow)
+	return nil
+}
+
+// VisitTryStmt lays out a try statement as:
+//
+//	    OpTry handler         (OpTryFinally without a catch clause)
+//	    <body>
+//	    OpEndTry
+//	    OpJump finally
+//	handler:                  (the thrown value is on the stack)
+//	    OpTryFinally rethrow  (only with a finally clause)
+//	    <catch body>
+//	    OpEndTry
+//	    OpJump caught
+//	rethrow:                  (the exception is on the stack)
+//	    <finally body>
+//	    OpThrow
+//	caught:
+//	    OpPop                 (the catch variable)
+//	finally:
+//	    <finally body>
+//
+// Without a catch clause the handler is the rethrow block.
+func (compiler *Compiler) VisitTryStmt(stmt *ast.TryStmt) error {
+	compiler.current = stmt.Keyword
+	try := tryCompiler{finally: stmt.FinallyBody, loop: compiler.loop}
+
+	handlerOp := vm.OpTry
+	if stmt.CatchBody == nil {
+		handlerOp = vm.OpTryFinally
+	}
+	handlerJump := compiler.emitJump(handlerOp)
+
+	compiler.tries = append(compiler.tries, try)
+	compiler.compileBlock(stmt.Body)
+	compiler.tries = compiler.tries[:len(compiler.tries)-1]
+	compiler.emitOp(vm.OpEndTry)
+	finallyJump := compiler.emitJump(vm.OpJump)
+
+	compiler.patchJump(handlerJump)
+	if stmt.CatchBody == nil {
+		compiler.compileRethrow(stmt.FinallyBody)
+	} else {
+		compiler.current = stmt.CatchName
+		compiler.beginScope()
+		compiler.addLocal(stmt.CatchName.Lexeme)
+		compiler.markInitialized()
+
+		if stmt.FinallyBody == nil {
+			compiler.compileBlock(stmt.CatchBody)
+		} else {
+			rethrowJump := compiler.emitJump(vm.OpTryFinally)
+			compiler.tries = append(compiler.tries, try)
+			compiler.compileBlock(stmt.CatchBody)
+			compiler.tries = compiler.tries[:len(compiler.tries)-1]
+			compiler.emitOp(vm.OpEndTry)
+			caughtJump := compiler.emitJump(vm.OpJump)
+
+			compiler.patchJump(rethrowJump)
+			compiler.compileRethrow(stmt.FinallyBody)
+			compiler.patchJump(caughtJump)
+		}
+
+		compiler.endScope()
+	}
+
+	compiler.patchJump(finallyJump)
+	if stmt.FinallyBody != nil {
+		compiler.compileBlock(stmt.FinallyBody)
+	}
+	return nil
+}
+
+// compileRethrow emits a finally clause for the case where it is reached by
+// an exception, which is on the stack and thrown again afterwards.
+func (compiler *Compiler) compileRethrow(finally []ast.Stmt) {
+	compiler.addTemporary()
+	compiler.compileBlock(finally)
+	compiler.emitOp(vm.OpThrow)
+	compiler.dropTemporary()
+}
+
+func (compiler *Compiler) VisitClassStmt(stmt *ast.ClassStmt) error {
+	compiler.current = stmt.Name
+	nameConstant := compiler.identifierConstant(stmt.Name.Lexeme)
+	compiler.declareVariable(stmt.Name)
+
+	compiler.emitOpShort(vm.OpClass, nameConstant)
+	compiler.defineVariable(stmt.Name)
+
+	class := &classCompiler{enclosing: compiler.class}
+	compiler.class = class
+	defer func() {
+		compiler.class = class.enclosing
+	}()
+
+	if stmt.Superclass != nil {
+		compiler.compileExpr(stmt.Superclass)
+
+		compiler.beginScope()
+		compiler.addLocal("super")
+		compiler.markInitialized()
+
+		compiler.namedVariable(stmt.Name, nil)
+		compiler.current = stmt.Superclass.Name
+		compiler.emitOp(vm.OpInherit)
+		class.hasSuperclass = true
+	}
+
+	compiler.namedVariable(stmt.Name, nil)
+	for _, method := range stmt.Methods {
+		kind := functionTypeMethod
+		if method.Name.Lexeme == "init" {
+			kind = functionTypeInitializer
+		}
+
+		compiler.compileFunction(method, kind)
+		compiler.emitOpShort(vm.OpMethod, compiler.identifierConstant(method.Name.Lexeme))
+	}
+	compiler.emitOp(vm.OpPop)
+
+	if class.hasSuperclass {
+		compiler.endScope()
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) VisitLiteralExpr(expr *ast.LiteralExpr) (interface{}, error) {
+	switch value := expr.Value.(type) {
+	case nil:
+		compiler.emitOp(vm.OpNil)
+	case bool:
+		if value {
+			compiler.emitOp(vm.OpTrue)
+		} else {
+			compiler.emitOp(vm.OpFalse)
+		}
+	case float64:
+		compiler.emitConstant(vm.NumberValue(value))
+	case string:
+		compiler.emitConstant(vm.StringValue(value))
+	}
+
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitGroupingExpr(expr *ast.GroupingExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Expression)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitUnaryExpr(expr *ast.UnaryExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Right)
+
+	compiler.current = expr.Operator
+	switch expr.Operator.Type {
+	case token.BANG:
+		compiler.emitOp(vm.OpNot)
+	case token.MINUS:
+		compiler.emitOp(vm.OpNegate)
+	}
+
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitBinaryExpr(expr *ast.BinaryExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Left)
+	compiler.compileExpr(expr.Right)
+
+	compiler.current = expr.Operator
+	switch expr.Operator.Type {
+	case token.BANG_EQUAL:
+		compiler.emitOp(vm.OpNotEqual)
+	case token.EQUAL_EQUAL:
+		compiler.emitOp(vm.OpEqual)
+	case token.GREATER:
+		compiler.emitOp(vm.OpGreater)
+	case token.GREATER_EQUAL:
+		compiler.emitOp(vm.OpGreaterEqual)
+	case token.LESS:
+		compiler.emitOp(vm.OpLess)
+	case token.LESS_EQUAL:
+		compiler.emitOp(vm.OpLessEqual)
+	case token.PLUS:
+		compiler.emitOp(vm.OpAdd)
+	case token.MINUS:
+		compiler.emitOp(vm.OpSubtract)
+	case token.STAR:
+		compiler.emitOp(vm.OpMultiply)
+	case token.SLASH:
+		compiler.emitOp(vm.OpDivide)
+	}
+
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitLogicalExpr(expr *ast.LogicalExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Left)
+
+	if expr.Operator.Type == token.OR {
+		elseJump := compiler.emitJump(vm.OpJumpIfFalse)
+		endJump := compiler.emitJump(vm.OpJump)
+
+		compiler.patchJump(elseJump)
+		compiler.emitOp(vm.OpPop)
+		compiler.compileExpr(expr.Right)
+		compiler.patchJump(endJump)
+	} else {
+		endJump := compiler.emitJump(vm.OpJumpIfFalse)
+		compiler.emitOp(vm.OpPop)
+		compiler.compileExpr(expr.Right)
+		compiler.patchJump(endJump)
+	}
+
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitVariableExpr(expr *ast.VariableExpr) (interface{}, error) {
+	compiler.namedVariable(expr.Name, nil)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitAssignExpr(expr *ast.AssignExpr) (interface{}, error) {
+	compiler.namedVariable(expr.Name, expr.Value)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitCallExpr(expr *ast.CallExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		compiler.compileExpr(argument)
+	}
+
+	compiler.current = expr.Paren
+	if len(expr.Arguments) > math.MaxUint8 {
+		compiler.error("Can't have more than 255 arguments.")
+	}
+	compiler.emitBytes(byte(vm.OpCall), byte(len(expr.Arguments)))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Object)
+
+	compiler.current = expr.Name
+	compiler.emitOpShort(vm.OpGetProperty, compiler.identifierConstant(expr.Name.Lexeme))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Object)
+	compiler.compileExpr(expr.Value)
+
+	compiler.current = expr.Name
+	compiler.emitOpShort(vm.OpSetProperty, compiler.identifierConstant(expr.Name.Lexeme))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitListExpr(expr *ast.ListExpr) (interface{}, error) {
+	for _, element := range expr.Elements {
+		compiler.compileExpr(element)
+	}
+
+	compiler.current = expr.Bracket
+	if len(expr.Elements) > math.MaxUint16 {
+		compiler.error("Too many elements in list literal.")
+	}
+	compiler.emitOpShort(vm.OpBuildList, len(expr.Elements))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitMapExpr(expr *ast.MapExpr) (interface{}, error) {
+	for i := range expr.Keys {
+		compiler.compileExpr(expr.Keys[i])
+		compiler.compileExpr(expr.Values[i])
+	}
+
+	compiler.current = expr.Brace
+	if len(expr.Keys) > math.MaxUint16 {
+		compiler.error("Too many entries in map literal.")
+	}
+	compiler.emitOpShort(vm.OpBuildMap, len(expr.Keys))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitInterpolationExpr(expr *ast.InterpolationExpr) (interface{}, error) {
+	for _, part := range expr.Parts {
+		compiler.compileExpr(part)
+	}
+
+	if len(expr.Parts) > math.MaxUint16 {
+		compiler.error("Too many parts in string interpolation.")
+	}
+	compiler.emitOpShort(vm.OpInterpolate, len(expr.Parts))
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitIndexExpr(expr *ast.IndexExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Object)
+	compiler.compileExpr(expr.Index)
+
+	compiler.current = expr.Bracket
+	compiler.emitOp(vm.OpGetIndex)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitIndexSetExpr(expr *ast.IndexSetExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Object)
+	compiler.compileExpr(expr.Index)
+	compiler.compileExpr(expr.Value)
+
+	compiler.current = expr.Bracket
+	compiler.emitOp(vm.OpSetIndex)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitSliceExpr(expr *ast.SliceExpr) (interface{}, error) {
+	compiler.compileExpr(expr.Object)
+	for _, bound := range []ast.Expr{expr.Start, expr.End} {
+		if bound == nil {
+			compiler.emitOp(vm.OpNil)
+		} else {
+			compiler.compileExpr(bound)
+		}
+	}
+
+	compiler.current = expr.Bracket
+	compiler.emitOp(vm.OpSlice)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	compiler.namedVariable(expr.Keyword, nil)
+	return nil, nil
+}
+
+func (compiler *Compiler) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	compiler.namedVariable(token.Token{Type: token.THIS, Lexeme: "this", Line: expr.Keyword.Line}, nil)
+	compiler.namedVariable(expr.Keyword, nil)
+
+	compiler.current = expr.Method
+	compiler.emitOpShort(vm.OpGetSuper, compiler.identifierConstant(expr.Method.Lexeme))
+	return nil, nil
+}
+
+func (compiler *Compiler) compileStmt(stmt ast.Stmt) {
+	stmt.Accept(compiler)
+}
+
+func (compiler *Compiler) compileExpr(expr ast.Expr) {
+	expr.Accept(compiler)
+}
+
+func (compiler *Compiler) compileBlock(statements []ast.Stmt) {
+	compiler.beginScope()
+	for _, stmt := range statements {
+		compiler.compileStmt(stmt)
+	}
+	compiler.endScope()
+}
+
+// compileFunction compiles the body of a function declaration with a fresh
+// Compiler and emits the instruction that creates its closure.
+func (compiler *Compiler) compileFunction(stmt *ast.FunctionStmt, kind functionType) {
+	inner := newCompiler(compiler, kind, stmt.Name.Lexeme)
+	inner.beginScope()
+
+	for _, param := range stmt.Parameters {
+		inner.function.Arity++
+		inner.current = param
+		inner.declareVariable(param)
+		inner.defineVariable(param)
+	}
+
+	for _, s := range stmt.Body {
+		inner.compileStmt(s)
+	}
+
+	function := inner.end()
+	compiler.hadError = compiler.hadError || inner.hadError
+
+	compiler.emitOpShort(vm.OpClosure, compiler.makeConstant(vm.ObjValue(function)))
+	for _, upvalue := range inner.upvalues {
+		isLocal := byte(0)
+		if upvalue.isLocal {
+			isLocal = 1
+		}
+		compiler.emitBytes(isLocal, upvalue.index)
+	}
+}
+
+func (compiler *Compiler) end() *vm.ObjFunction {
+	compiler.emitReturn()
+	compiler.function.UpvalueCount = len(compiler.upvalues)
+	return compiler.function
+}
+
+func (compiler *Compiler) beginScope() {
+	compiler.scopeDepth++
+}
+
+func (compiler *Compiler) endScope() {
+	compiler.scopeDepth--
+
+	for len(compiler.locals) > 0 && compiler.locals[len(compiler.locals)-1].depth > compiler.scopeDepth {
+		if compiler.locals[len(compiler.locals)-1].isCaptured {
+			compiler.emitOp(vm.OpCloseUpvalue)
+		} else {
+			compiler.emitOp(vm.OpPop)
+		}
+		compiler.locals = compiler.locals[:len(compiler.locals)-1]
+	}
+}
+
+// popLocals emits the instructions to discard the locals deeper than depth
+// without forgetting them, for jumps that leave their scope early.
+func (compiler *Compiler) popLocals(depth int) {
+	for i := len(compiler.locals) - 1; i >= 0 && compiler.locals[i].depth > depth; i-- {
+		if compiler.locals[i].isCaptured {
+			compiler.emitOp(vm.OpCloseUpvalue)
+		} else {
+			compiler.emitOp(vm.OpPop)
+		}
+	}
+}
+
+// exitTries emits the code to leave the try blocks nested deeper than
+// depth: each handler is removed and its finally clause, if any, is run.
+func (compiler *Compiler) exitTries(depth int) {
+	tries, loop := compiler.tries, compiler.loop
+	for i := len(tries) - 1; i >= depth; i-- {
+		compiler.emitOp(vm.OpEndTry)
+		if tries[i].finally != nil {
+			// The clause is compiled as if it appeared after the try
+			// statement, outside its own handler.
+			compiler.tries, compiler.loop = tries[:i], tries[i].loop
+			compiler.compileBlock(tries[i].finally)
+		}
+	}
+	compiler.tries, compiler.loop = tries, loop
+}
+
+// addTemporary reserves a stack slot, in a scope of its own, for a value
+// the program can't name.
+func (compiler *Compiler) addTemporary() {
+	compiler.beginScope()
+	compiler.addLocal("")
+	compiler.markInitialized()
+}
+
+// dropTemporary forgets the slot reserved by addTemporary without popping
+// it. It follows an instruction, such as OpReturn, that never falls through.
+func (compiler *Compiler) dropTemporary() {
+	compiler.scopeDepth--
+	compiler.locals = compiler.locals[:len(compiler.locals)-1]
+}
+
+// namedVariable emits a load of the variable, or a store if value is not
+// nil. Locals are looked up first, then upvalues, falling back to globals.
+func (compiler *Compiler) namedVariable(name token.Token, value ast.Expr) {
+	var getOp, setOp vm.OpCode
+	var arg int
+
+	if slot := compiler.resolveLocal(name); slot != -1 {
+		getOp, setOp, arg = vm.OpGetLocal, vm.OpSetLocal, slot
+	} else if index := compiler.resolveUpvalue(name); index != -1 {
+		getOp, setOp, arg = vm.OpGetUpvalue, vm.OpSetUpvalue, index
+	} else {
+		compiler.current = name
+		constant := compiler.identifierConstant(name.Lexeme)
+		if value != nil {
+			compiler.compileExpr(value)
+			compiler.current = name
+			compiler.emitOpShort(vm.OpSetGlobal, constant)
+		} else {
+			compiler.emitOpShort(vm.OpGetGlobal, constant)
+		}
+		return
+	}
+
+	if value != nil {
+		compiler.compileExpr(value)
+		compiler.current = name
+		compiler.emitBytes(byte(setOp), byte(arg))
+	} else {
+		compiler.current = name
+		compiler.emitBytes(byte(getOp), byte(arg))
+	}
+}
+
+func (compiler *Compiler) resolveLocal(name token.Token) int {
+	for i := len(compiler.locals) - 1; i >= 0; i-- {
+		if compiler.locals[i].name == name.Lexeme {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (compiler *Compiler) resolveUpvalue(name token.Token) int {
+	if compiler.enclosing == nil {
+		return -1
+	}
+
+	if slot := compiler.enclosing.resolveLocal(name); slot != -1 {
+		compiler.enclosing.locals[slot].isCaptured = true
+		return compiler.addUpvalue(uint8(slot), true)
+	}
+
+	if index := compiler.enclosing.resolveUpvalue(name); index != -1 {
+		return compiler.addUpvalue(uint8(index), false)
+	}
+
+	return -1
+}
+
+func (compiler *Compiler) addUpvalue(index uint8, isLocal bool) int {
+	for i, upvalue := range compiler.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+
+	if len(compiler.upvalues) == upvaluesMax {
+		compiler.error("Too many closure variables in function.")
+		return 0
+	}
+
+	compiler.upvalues = append(compiler.upvalues, upvalue{index: index, isLocal: isLocal})
+	return len(compiler.upvalues) - 1
+}
+
+func (compiler *Compiler) declareVariable(name token.Token) {
+	if compiler.scopeDepth == 0 {
+		return
+	}
+
+	compiler.addLocal(name.Lexeme)
+}
+
+func (compiler *Compiler) addLocal(name string) {
+	if len(compiler.locals) == localsMax {
+		compiler.error("Too many local variables in function.")
+		return
+	}
+
+	compiler.locals = append(compiler.locals, local{name: name, depth: -1})
+}
+
+func (compiler *Compiler) defineVariable(name token.Token) {
+	if compiler.scopeDepth > 0 {
+		compiler.markInitialized()
+		return
+	}
+
+	compiler.emitOpShort(vm.OpDefineGlobal, compiler.identifierConstant(name.Lexeme))
+}
+
+func (compiler *Compiler) markInitialized() {
+	if compiler.scopeDepth == 0 {
+		return
+	}
+
+	compiler.locals[len(compiler.locals)-1].depth = compiler.scopeDepth
+}
+
+func (compiler *Compiler) identifierConstant(name string) int {
+	return compiler.makeConstant(vm.StringValue(name))
+}
+
+func (compiler *Compiler) chunk() *vm.Chunk {
+	return &compiler.function.Chunk
+}
+
+func (compiler *Compiler) makeConstant(value vm.Value) int {
+	constant := compiler.chunk().AddConstant(value)
+	if constant > math.MaxUint16 {
+		compiler.error("Too many constants in one chunk.")
+		return 0
+	}
+
+	return constant
+}
+
+func (compiler *Compiler) emitConstant(value vm.Value) {
+	compiler.emitOpShort(vm.OpConstant, compiler.makeConstant(value))
+}
+
+func (compiler *Compiler) emitReturn() {
+	compiler.emitDefaultResult()
+	compiler.emitOp(vm.OpReturn)
+}
+
+// emitDefaultResult pushes what a function returns when no value is given:
+// the receiver for initializers and nil for everything else.
+func (compiler *Compiler) emitDefaultResult() {
+	if compiler.kind == functionTypeInitializer {
+		compiler.emitBytes(byte(vm.OpGetLocal), 0)
+	} else {
+		compiler.emitOp(vm.OpNil)
+	}
+}
+
+func (compiler *Compiler) emitJump(op vm.OpCode) int {
+	compiler.emitOp(op)
+	compiler.emitBytes(0xff, 0xff)
+	return len(compiler.chunk().Code) - 2
+}
+
+// patchJump backfills the operand of a jump emitted by emitJump so that it
+// lands on the next instruction to be emitted.
+func (compiler *Compiler) patchJump(offset int) {
+	jump := len(compiler.chunk().Code) - offset - 2
+	if jump > math.MaxUint16 {
+		compiler.error("Too much code to jump over.")
+	}
+
+	compiler.chunk().Code[offset] = byte(jump >> 8)
+	compiler.chunk().Code[offset+1] = byte(jump)
+}
+
+func (compiler *Compiler) emitLoop(loopStart int) {
+	compiler.emitOp(vm.OpLoop)
+
+	offset := len(compiler.chunk().Code) - loopStart + 2
+	if offset > math.MaxUint16 {
+		compiler.error("Loop body too large.")
+	}
+
+	compiler.emitBytes(byte(offset>>8), byte(offset))
+}
+
+func (compiler *Compiler) emitOp(op vm.OpCode) {
+	compiler.chunk().Write(byte(op), compiler.current.Line, compiler.current.Span)
+}
+
+func (compiler *Compiler) emitOpShort(op vm.OpCode, operand int) {
+	compiler.emitOp(op)
+	compiler.emitBytes(byte(operand>>8), byte(operand))
+}
+
+func (compiler *Compiler) emitBytes(bytes ...byte) {
+	for _, b := range bytes {
+		compiler.chunk().Write(b, compiler.current.Line, compiler.current.Span)
+	}
+}
+
+func (compiler *Compiler) error(message string) {
+	compiler.hadError = true
+	compiler.diagnostics.Report(loxerror.NewSyntaxError(compiler.current.Line, message))
+}
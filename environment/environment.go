@@ -26,10 +26,21 @@ func NewEnvironment(enclosing *Environment) *Environment {
 	}
 }
 
+func (environment *Environment) Enclosing() *Environment {
+	return environment.enclosing
+}
+
 func (environment *Environment) Define(name string, value interface{}) {
 	environment.values[name] = value
 }
 
+// Lookup finds a variable defined in this environment itself, ignoring the
+// ones it encloses.
+func (environment *Environment) Lookup(name string) (interface{}, bool) {
+	value, isPresent := environment.values[name]
+	return value, isPresent
+}
+
 func (environment *Environment) Get(name token.Token) (interface{}, error) {
 	if value, isPresent := environment.values[name.Lexeme]; isPresent {
 		return value, nil
@@ -39,7 +50,7 @@ func (environment *Environment) Get(name token.Token) (interface{}, error) {
 		return environment.enclosing.Get(name)
 	}
 
-	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme))
+	return nil, undefinedVariable(name)
 }
 
 func (environment *Environment) Assign(name token.Token, value interface{}) error {
@@ -52,5 +63,30 @@ func (environment *Environment) Assign(name token.Token, value interface{}) erro
 		return environment.enclosing.Assign(name, value)
 	}
 
-	return loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme))
+	return undefinedVariable(name)
+}
+
+// GetAt reads a variable from the environment exactly distance hops up the
+// chain. The resolver guarantees that the variable is present there.
+func (environment *Environment) GetAt(distance int, name string) interface{} {
+	return environment.ancestor(distance).values[name]
+}
+
+func (environment *Environment) AssignAt(distance int, name token.Token, value interface{}) {
+	environment.ancestor(distance).values[name.Lexeme] = value
+}
+
+func (environment *Environment) ancestor(distance int) *Environment {
+	ancestor := environment
+	for i := 0; i < distance; i++ {
+		ancestor = ancestor.enclosing
+	}
+
+	return ancestor
+}
+
+func undefinedVariable(name token.Token) error {
+	message := fmt.Sprintf("Undefined variable '%s'.", name.Lexeme)
+	help := fmt.Sprintf("declare it with 'var %s;' before using it", name.Lexeme)
+	return loxerror.NewRuntimeError(name, message).WithHelp(help)
 }
@@ -0,0 +1,214 @@
+package golox_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectStaticError  = regexp.MustCompile(`// (\[line (\d+)\] )?(Error( at ('.*'|end))?: .*)$`)
+)
+
+// TestConformance runs every script under testdata on both backends and
+// checks what it does against annotations in its comments, in the style of
+// the Crafting Interpreters test suite:
+//
+//	print 1 + 2;  // expect: 3
+//	nil + 1;      // expect runtime error: Operands must be two numbers or two strings.
+//	var a = ;     // Error at ';': Expect expression
+//	// [line 9] Error at end: Expect '}' after block.
+//
+// A static error annotation without a line refers to the line it is on.
+// Scripts whose names start with an underscore are modules imported by
+// other scripts and aren't run by themselves.
+func TestConformance(t *testing.T) {
+	scripts := findScripts(t)
+	backends := []struct {
+		name     string
+		bytecode bool
+	}{
+		{"interpreter", false},
+		{"vm", true},
+	}
+	for _, backend := range backends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			for _, script := range scripts {
+				script := script
+				name := strings.TrimSuffix(filepath.ToSlash(strings.TrimPrefix(script, "testdata"+string(filepath.Separator))), ".lox")
+				t.Run(name, func(t *testing.T) {
+					runScript(t, script, backend.bytecode)
+				})
+			}
+		})
+	}
+}
+
+// TestBackendsRenderErrorsAlike checks that the errors each script reports
+// are shown the same way by both backends, down to the column they point at.
+func TestBackendsRenderErrorsAlike(t *testing.T) {
+	for _, script := range findScripts(t) {
+		source, err := os.ReadFile(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rendered [2]string
+		for i, bytecode := range []bool{false, true} {
+			var stdout, stderr, errors bytes.Buffer
+			lox := golox.New(golox.Options{Stdout: &stdout, Stderr: &stderr, Bytecode: bytecode, FileSystem: true})
+			lox.EvalFile(context.Background(), script, string(source))
+			loxerror.NewRenderer(script, string(source), false).RenderAll(&errors, lox.Diagnostics())
+			rendered[i] = errors.String()
+		}
+
+		if rendered[0] != rendered[1] {
+			t.Errorf("%s: the interpreter reported\n%s\nbut the vm reported\n%s", script, rendered[0], rendered[1])
+		}
+	}
+}
+
+func findScripts(t *testing.T) []string {
+	var scripts []string
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(path) == ".lox" && !strings.HasPrefix(entry.Name(), "_") {
+			scripts = append(scripts, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) == 0 {
+		t.Fatal("no scripts found under testdata")
+	}
+
+	return scripts
+}
+
+type expectations struct {
+	output       []string
+	runtimeError string
+	runtimeLine  int
+	staticErrors []string
+}
+
+func parseExpectations(t *testing.T, source string) expectations {
+	var expected expectations
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := expectOutput.FindStringSubmatch(text); match != nil {
+			expected.output = append(expected.output, match[1])
+		} else if match := expectRuntimeError.FindStringSubmatch(text); match != nil {
+			if expected.runtimeError != "" {
+				t.Fatalf("line %d: only one runtime error can be expected", line)
+			}
+			expected.runtimeError = match[1]
+			expected.runtimeLine = line
+		} else if match := expectStaticError.FindStringSubmatch(text); match != nil {
+			errorLine := line
+			if match[2] != "" {
+				errorLine, _ = strconv.Atoi(match[2])
+			}
+			expected.staticErrors = append(expected.staticErrors, fmt.Sprintf("[line %d] %s", errorLine, match[3]))
+		}
+	}
+
+	return expected
+}
+
+func runScript(t *testing.T, path string, bytecode bool) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := parseExpectations(t, string(source))
+
+	var stdout, stderr bytes.Buffer
+	lox := golox.New(golox.Options{Stdout: &stdout, Stderr: &stderr, Bytecode: bytecode, FileSystem: true})
+	lox.EvalFile(context.Background(), path, string(source))
+
+	var staticErrors []string
+	var runtimeErrors []loxerror.Diagnostic
+	for _, diagnostic := range lox.Diagnostics().All() {
+		if diagnostic.Kind == loxerror.KindRuntime {
+			runtimeErrors = append(runtimeErrors, diagnostic)
+		} else {
+			staticErrors = append(staticErrors, formatStaticError(diagnostic))
+		}
+	}
+
+	output := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if stdout.Len() == 0 {
+		output = nil
+	}
+	if !equalLines(output, expected.output) {
+		t.Errorf("output:\n%s\nwant:\n%s", strings.Join(output, "\n"), strings.Join(expected.output, "\n"))
+	}
+
+	sort.Strings(staticErrors)
+	sort.Strings(expected.staticErrors)
+	if !equalLines(staticErrors, expected.staticErrors) {
+		t.Errorf("static errors:\n%s\nwant:\n%s", strings.Join(staticErrors, "\n"), strings.Join(expected.staticErrors, "\n"))
+	}
+
+	switch {
+	case expected.runtimeError == "" && len(runtimeErrors) > 0:
+		t.Errorf("unexpected runtime error: %s", runtimeErrors[0])
+	case expected.runtimeError != "" && len(runtimeErrors) == 0:
+		t.Errorf("missing runtime error on line %d: %s", expected.runtimeLine, expected.runtimeError)
+	case expected.runtimeError != "":
+		got := runtimeErrors[0]
+		if got.Message != expected.runtimeError || got.Line != expected.runtimeLine {
+			t.Errorf("runtime error on line %d: %s\nwant on line %d: %s", got.Line, got.Message, expected.runtimeLine, expected.runtimeError)
+		}
+	}
+}
+
+// formatStaticError prints a static error the way the annotations write
+// it.
+func formatStaticError(diagnostic loxerror.Diagnostic) string {
+	where := ""
+	if parseError, isParseError := diagnostic.Err.(*loxerror.ParseError); isParseError {
+		if parseError.Token().Type == token.EOF {
+			where = " at end"
+		} else {
+			where = fmt.Sprintf(" at '%s'", parseError.Token().Lexeme)
+		}
+	}
+
+	return fmt.Sprintf("[line %d] Error%s: %s", diagnostic.Line, where, diagnostic.Message)
+}
+
+func equalLines(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
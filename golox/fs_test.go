@@ -0,0 +1,70 @@
+package golox_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+)
+
+const fsScript = `
+var dir = scratch();
+fs.mkdir(dir + "/nested");
+print fs.exists(dir + "/nested");
+
+var path = dir + "/notes.txt";
+fs.writeFile(path, "one\n");
+fs.appendFile(path, "two\n");
+print fs.readLines(path);
+
+fs.writeFile(path, "replaced");
+print fs.readFile(path);
+
+var file = fs.open(dir + "/log.txt", "w");
+file.write("written");
+file.close();
+print fs.readFile(dir + "/log.txt");
+
+print fs.listDir(dir);
+
+try {
+  fs.remove(dir);
+} catch (e) {
+  print e.message.startsWith("Can't remove '" + dir + "':");
+}
+
+fs.remove(path);
+fs.remove(dir + "/log.txt");
+fs.remove(dir + "/nested");
+print fs.listDir(dir);
+`
+
+func TestFileSystemWrites(t *testing.T) {
+	want := strings.Join([]string{
+		"true",
+		`["one", "two"]`,
+		"replaced",
+		"written",
+		`["log.txt", "nested", "notes.txt"]`,
+		"true",
+		"[]",
+	}, "\n") + "\n"
+
+	for _, bytecode := range []bool{false, true} {
+		dir := t.TempDir()
+		var stdout bytes.Buffer
+		lox := golox.New(golox.Options{Stdout: &stdout, Bytecode: bytecode, FileSystem: true})
+		if err := lox.Register("scratch", func() string { return dir }); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := lox.Eval(context.Background(), fsScript); err != nil {
+			t.Fatalf("bytecode=%v: %v", bytecode, err)
+		}
+		if got := stdout.String(); got != want {
+			t.Errorf("bytecode=%v: printed\n%s\nwant\n%s", bytecode, got, want)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Package golox runs Lox scripts from Go programs.
+//
+//	lox := golox.New(golox.Options{Stdout: &buf})
+//	if err := lox.Eval(ctx, `print "hello";`); err != nil {
+//		...
+//	}
+//
+// Globals defined by one call to Eval remain visible to later calls on the
+// same Runtime. A Runtime must not be used from several goroutines at once.
+package golox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordanwebster/golox/ast"
+	"github.com/jordanwebster/golox/compiler"
+	"github.com/jordanwebster/golox/interpreter"
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/loxio"
+	"github.com/jordanwebster/golox/native"
+	"github.com/jordanwebster/golox/parser"
+	"github.com/jordanwebster/golox/resolver"
+	"github.com/jordanwebster/golox/scanner"
+	"github.com/jordanwebster/golox/token"
+	"github.com/jordanwebster/golox/vm"
+)
+
+type Options struct {
+	// Stdout receives the output of print statements and the write native.
+	// Defaults to os.Stdout.
+	Stdout io.Writer
+	// Stderr receives the output of the eprint native and the errors
+	// reported by RunPrompt. Defaults to os.Stderr.
+	Stderr io.Writer
+	// Bytecode selects the bytecode virtual machine instead of the
+	// tree-walking interpreter.
+	Bytecode bool
+	// FileSystem defines the fs global, which lets scripts read, write and
+	// delete files. It is off by default so that embedded scripts can't
+	// touch the file system.
+	FileSystem bool
+}
+
+// CompileError is returned when a script fails to scan, parse or resolve.
+// It holds every error found rather than just the first.
+type CompileError struct {
+	Errors []error
+}
+
+func (e *CompileError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func (e *CompileError) Unwrap() []error {
+	return e.Errors
+}
+
+type Runtime struct {
+	stdout io.Writer
+	stderr io.Writer
+	// path is the file the script being run was read from, if any.
+	path        string
+	diagnostics *loxerror.Diagnostics
+	interpreter *interpreter.Interpreter
+	vm          *vm.VM
+}
+
+func New(opts Options) *Runtime {
+	runtime := &Runtime{
+		stdout:      opts.Stdout,
+		stderr:      opts.Stderr,
+		diagnostics: loxerror.NewDiagnostics(),
+	}
+
+	if runtime.stdout == nil {
+		runtime.stdout = os.Stdout
+	}
+	if runtime.stderr == nil {
+		runtime.stderr = os.Stderr
+	}
+
+	if opts.Bytecode {
+		runtime.vm = vm.NewVM(runtime.stdout, runtime.stderr, runtime.diagnostics)
+		runtime.vm.SetModuleLoader(bytecodeLoader{moduleLoader{runtime}})
+	} else {
+		runtime.interpreter = interpreter.NewInterpreter(runtime.stdout, runtime.stderr, runtime.diagnostics)
+		runtime.interpreter.SetModuleLoader(moduleLoader{runtime})
+	}
+
+	if opts.FileSystem {
+		if runtime.vm == nil {
+			runtime.interpreter.DefineFileSystem()
+		} else {
+			runtime.vm.DefineFileSystem()
+		}
+	}
+
+	return runtime
+}
+
+// Register makes a Go function available to scripts as a global with the
+// given name. Arguments are converted to the function's parameter types and
+// the result converted back, so for example
+//
+//	lox.Register("shout", func(s string, times int) (string, error) { ... })
+//
+// can be called as shout("hi", 3). Variadic functions accept any number of
+// trailing arguments. A non-nil error returned by the function becomes a
+// runtime error at the call site.
+func (runtime *Runtime) Register(name string, fn interface{}) error {
+	function, err := native.Wrap(name, fn)
+	if err != nil {
+		return err
+	}
+
+	if runtime.vm == nil {
+		runtime.interpreter.DefineNative(function)
+	} else {
+		runtime.vm.DefineGoFunction(function)
+	}
+
+	return nil
+}
+
+// Diagnostics holds every error reported by the most recent call to Eval.
+func (runtime *Runtime) Diagnostics() *loxerror.Diagnostics {
+	return runtime.diagnostics
+}
+
+// Eval runs Lox source code. Static errors are returned together as a
+// *CompileError without running anything; a failure while running is
+// returned as a *loxerror.RuntimeError. If ctx is cancelled the script is
+// stopped and ctx.Err() is returned. Either way the errors are also
+// available from Diagnostics until the next call.
+//
+// Modules imported by the source are found relative to the working
+// directory.
+func (runtime *Runtime) Eval(ctx context.Context, source string) error {
+	runtime.path = ""
+	return runtime.eval(ctx, source)
+}
+
+// EvalFile is like Eval for source that was read from path, so that modules
+// it imports are found relative to path.
+func (runtime *Runtime) EvalFile(ctx context.Context, path string, source string) error {
+	runtime.path = path
+	return runtime.eval(ctx, source)
+}
+
+func (runtime *Runtime) eval(ctx context.Context, source string) error {
+	runtime.diagnostics.Clear()
+
+	stmts := parse(strings.NewReader(source), runtime.diagnostics)
+	if !runtime.diagnostics.HasErrors() {
+		runtime.newResolver(runtime.diagnostics).Resolve(stmts)
+	}
+	if runtime.diagnostics.HasErrors() {
+		return &CompileError{Errors: runtime.diagnostics.Errors()}
+	}
+
+	return runtime.run(ctx, stmts, runtime.diagnostics)
+}
+
+// RunFile reads a script from disk and evaluates it.
+func (runtime *Runtime) RunFile(path string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return runtime.EvalFile(context.Background(), path, string(source))
+}
+
+// RunPrompt starts an interactive session reading from input. Each
+// statement runs as soon as it has been parsed and errors are written to
+// Stderr rather than ending the session.
+func (runtime *Runtime) RunPrompt(input io.Reader) {
+	report := func(diagnostic loxerror.Diagnostic) {
+		fmt.Fprintln(runtime.stderr, diagnostic)
+	}
+
+	runtime.diagnostics.Clear()
+	runtime.diagnostics.OnReport(report)
+	defer runtime.diagnostics.OnReport(nil)
+
+	readWriter := loxio.NewChannelReadWriter()
+	go runtime.readLines(input, readWriter)
+
+	tokens := make(chan token.Token)
+	scanner := scanner.NewScanner(readWriter, tokens, runtime.diagnostics)
+	go scanner.ScanTokens()
+
+	statements := make(chan ast.Stmt)
+	parser := parser.NewParser(tokens, statements, runtime.diagnostics)
+	go parser.Parse()
+
+	for stmt := range statements {
+		// The parser keeps reporting into the shared diagnostics while this
+		// statement runs, so static errors for it are collected separately.
+		diagnostics := loxerror.NewDiagnostics()
+		diagnostics.OnReport(report)
+
+		runtime.newResolver(diagnostics).Resolve([]ast.Stmt{stmt})
+		if diagnostics.HasErrors() {
+			continue
+		}
+
+		// Runtime errors have already been reported to the diagnostics.
+		runtime.run(context.Background(), []ast.Stmt{stmt}, diagnostics)
+	}
+}
+
+func (runtime *Runtime) readLines(input io.Reader, writer *loxio.ChannelReadWriter) {
+	lineScanner := bufio.NewScanner(input)
+	fmt.Fprint(runtime.stdout, "> ")
+	for lineScanner.Scan() {
+		writer.Write(lineScanner.Bytes())
+		fmt.Fprint(runtime.stdout, "> ")
+	}
+
+	writer.Close()
+}
+
+func (runtime *Runtime) run(ctx context.Context, stmts []ast.Stmt, diagnostics *loxerror.Diagnostics) error {
+	if runtime.vm == nil {
+		return runtime.interpreter.Interpret(ctx, stmts)
+	}
+
+	function := compiler.Compile(stmts, diagnostics)
+	if function == nil {
+		return &CompileError{Errors: diagnostics.Errors()}
+	}
+
+	return runtime.vm.Interpret(ctx, function)
+}
+
+func (runtime *Runtime) newResolver(diagnostics *loxerror.Diagnostics) *resolver.Resolver {
+	if runtime.vm == nil {
+		return resolver.NewResolver(runtime.interpreter, diagnostics)
+	}
+
+	// The compiler tracks its own locals so only the static checks matter.
+	return resolver.NewResolver(discardLocals{}, diagnostics)
+}
+
+// findModule locates the file an import refers to. Relative paths are tried
+// against the directory of the importing file and then against each
+// directory listed in the LOXPATH environment variable. The path returned is
+// absolute, as it identifies the module, so that a file reached both
+// relatively and through LOXPATH is only loaded once.
+func (runtime *Runtime) findModule(importer string, path string) (string, error) {
+	if importer == "" {
+		importer = runtime.path
+	}
+
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{filepath.Join(filepath.Dir(importer), path)}
+		for _, dir := range filepath.SplitList(os.Getenv("LOXPATH")) {
+			if dir != "" {
+				candidates = append(candidates, filepath.Join(dir, path))
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	return "", fmt.Errorf("Can't find module '%s'.", path)
+}
+
+// parseModule reads, parses and resolves an imported file. Its static errors
+// are reported against the file and the import fails.
+func (runtime *Runtime) parseModule(file string, diagnostics *loxerror.Diagnostics) ([]ast.Stmt, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read module '%s'.", file)
+	}
+
+	stmts := parse(bytes.NewReader(source), diagnostics)
+	if !diagnostics.HasErrors() {
+		runtime.newResolver(diagnostics).Resolve(stmts)
+	}
+	if diagnostics.HasErrors() {
+		return nil, runtime.moduleErrors(file, diagnostics)
+	}
+
+	return stmts, nil
+}
+
+func (runtime *Runtime) moduleErrors(file string, diagnostics *loxerror.Diagnostics) error {
+	runtime.diagnostics.Merge(diagnostics, file)
+	return fmt.Errorf("Module '%s' has errors.", file)
+}
+
+// moduleLoader lets the interpreter import modules.
+type moduleLoader struct {
+	runtime *Runtime
+}
+
+func (loader moduleLoader) Find(importer string, path string) (string, error) {
+	return loader.runtime.findModule(importer, path)
+}
+
+func (loader moduleLoader) Load(file string) ([]ast.Stmt, error) {
+	return loader.runtime.parseModule(file, loxerror.NewDiagnostics())
+}
+
+// bytecodeLoader lets the VM import modules.
+type bytecodeLoader struct {
+	moduleLoader
+}
+
+func (loader bytecodeLoader) Load(file string) (*vm.ObjFunction, error) {
+	diagnostics := loxerror.NewDiagnostics()
+	stmts, err := loader.runtime.parseModule(file, diagnostics)
+	if err != nil {
+		return nil, err
+	}
+
+	function := compiler.Compile(stmts, diagnostics)
+	if function == nil {
+		return nil, loader.runtime.moduleErrors(file, diagnostics)
+	}
+
+	return function, nil
+}
+
+func parse(source io.Reader, diagnostics *loxerror.Diagnostics) []ast.Stmt {
+	tokens := make(chan token.Token)
+	scanner := scanner.NewScanner(source, tokens, diagnostics)
+	go scanner.ScanTokens()
+
+	statements := make(chan ast.Stmt)
+	parser := parser.NewParser(tokens, statements, diagnostics)
+	go parser.Parse()
+
+	stmts := make([]ast.Stmt, 0, 64)
+	for stmt := range statements {
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts
+}
+
+type discardLocals struct{}
+
+func (discardLocals) Resolve(expr ast.Expr, depth int) {}
@@ -0,0 +1,76 @@
+package golox_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+)
+
+func TestImportCycle(t *testing.T) {
+	a, err := filepath.Abs(filepath.Join("testdata", "module", "_cycle_a.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := filepath.Join(filepath.Dir(a), "_cycle_b.lox")
+	script := filepath.Join(filepath.Dir(a), "cycle.lox")
+	want := "Import cycle: " + strings.Join([]string{a, b, a}, " -> ") + "."
+
+	for _, bytecode := range []bool{false, true} {
+		lox := golox.New(golox.Options{Stdout: &bytes.Buffer{}, Bytecode: bytecode})
+		if err := lox.EvalFile(context.Background(), script, `import "_cycle_a.lox" as a;`); err == nil {
+			t.Fatalf("bytecode=%v: the import cycle wasn't reported", bytecode)
+		}
+		if got := lox.Diagnostics().All()[0].Message; got != want {
+			t.Errorf("bytecode=%v: got %q, want %q", bytecode, got, want)
+		}
+	}
+}
+
+// TestModuleLoadedOnceThroughLoxPath imports the same module relatively and
+// through a relative LOXPATH entry, which name the file differently.
+func TestModuleLoadedOnceThroughLoxPath(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"counter.lox":    `print "loading counter";`,
+		"sub/second.lox": `import "counter.lox" as counter;`,
+	}
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loxPath, err := filepath.Rel(wd, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LOXPATH", loxPath)
+
+	script := `
+import "counter.lox" as first;
+import "sub/second.lox" as second;
+`
+	for _, bytecode := range []bool{false, true} {
+		var stdout bytes.Buffer
+		lox := golox.New(golox.Options{Stdout: &stdout, Bytecode: bytecode})
+		if err := lox.EvalFile(context.Background(), filepath.Join(dir, "main.lox"), script); err != nil {
+			t.Fatalf("bytecode=%v: %v", bytecode, err)
+		}
+		if got := stdout.String(); got != "loading counter\n" {
+			t.Errorf("bytecode=%v: printed %q, want the module loaded once", bytecode, got)
+		}
+	}
+}
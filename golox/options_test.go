@@ -0,0 +1,68 @@
+package golox_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+)
+
+func TestFileSystemIsOptIn(t *testing.T) {
+	for _, bytecode := range []bool{false, true} {
+		lox := golox.New(golox.Options{Stdout: &bytes.Buffer{}, Bytecode: bytecode})
+		if err := lox.Eval(context.Background(), `fs.exists(".");`); err == nil {
+			t.Errorf("bytecode=%v: fs is defined without the FileSystem option", bytecode)
+		}
+
+		lox = golox.New(golox.Options{Stdout: &bytes.Buffer{}, Bytecode: bytecode, FileSystem: true})
+		if err := lox.Eval(context.Background(), `fs.exists(".");`); err != nil {
+			t.Errorf("bytecode=%v: %v", bytecode, err)
+		}
+	}
+}
+
+func TestErrorsGoToStderr(t *testing.T) {
+	for _, bytecode := range []bool{false, true} {
+		var stdout, stderr bytes.Buffer
+		lox := golox.New(golox.Options{Stdout: &stdout, Stderr: &stderr, Bytecode: bytecode})
+		if err := lox.Eval(context.Background(), `print "out"; eprint("err");`); err != nil {
+			t.Fatalf("bytecode=%v: %v", bytecode, err)
+		}
+		if stdout.String() != "out\n" || stderr.String() != "err\n" {
+			t.Errorf("bytecode=%v: stdout %q and stderr %q, want \"out\\n\" and \"err\\n\"", bytecode, stdout.String(), stderr.String())
+		}
+
+		// The prompt reports errors as it goes rather than returning them.
+		var promptOut, promptErr lockedBuffer
+		lox = golox.New(golox.Options{Stdout: &promptOut, Stderr: &promptErr, Bytecode: bytecode})
+		lox.RunPrompt(strings.NewReader("print \"out\";\nnil + 1;\n"))
+		if !strings.Contains(promptErr.String(), "Operands must be two numbers or two strings.") {
+			t.Errorf("bytecode=%v: stderr %q doesn't report the runtime error", bytecode, promptErr.String())
+		}
+		if out := promptOut.String(); !strings.Contains(out, "out\n") || strings.Contains(out, "Operands") {
+			t.Errorf("bytecode=%v: stdout %q should hold only the script's output", bytecode, out)
+		}
+	}
+}
+
+// lockedBuffer is a bytes.Buffer that the prompt can write to from the
+// goroutine reading its input and from the one running statements.
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *lockedBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(p)
+}
+
+func (buffer *lockedBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
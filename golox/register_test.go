@@ -0,0 +1,77 @@
+package golox_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+)
+
+func TestRegisterRejectsUncallableParameters(t *testing.T) {
+	functions := map[string]interface{}{
+		"slice":  func([]string) {},
+		"map":    func(map[string]interface{}) {},
+		"struct": func(struct{}) {},
+	}
+
+	for name, fn := range functions {
+		lox := golox.New(golox.Options{})
+		if err := lox.Register(name, fn); err == nil {
+			t.Errorf("Register accepted a function with a %s parameter", name)
+		}
+	}
+}
+
+func TestRegisterRejectsUnsupportedResults(t *testing.T) {
+	type point struct{ x int }
+	functions := map[string]interface{}{
+		"goMap":     func() map[string]int { return map[string]int{"a": 1} },
+		"goPointer": func() *point { return &point{1} },
+	}
+	want := map[string]string{
+		"goMap":     "Native function 'goMap' returned an unsupported map[string]int.",
+		"goPointer": "Native function 'goPointer' returned an unsupported *golox_test.point.",
+	}
+
+	for _, bytecode := range []bool{false, true} {
+		for name, fn := range functions {
+			var stdout bytes.Buffer
+			lox := golox.New(golox.Options{Stdout: &stdout, Bytecode: bytecode})
+			if err := lox.Register(name, fn); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := lox.Eval(context.Background(), "print "+name+"();"); err == nil {
+				t.Errorf("bytecode=%v: %s() succeeded and printed %q", bytecode, name, stdout.String())
+				continue
+			}
+			if got := lox.Diagnostics().All()[0].Message; got != want[name] {
+				t.Errorf("bytecode=%v: %s() failed with %q, want %q", bytecode, name, got, want[name])
+			}
+		}
+	}
+}
+
+func TestRegisteredPanicsBecomeErrors(t *testing.T) {
+	script := `
+try {
+  explode();
+} catch (e) {
+  print e.message;
+}
+`
+	for _, bytecode := range []bool{false, true} {
+		var stdout bytes.Buffer
+		lox := golox.New(golox.Options{Stdout: &stdout, Bytecode: bytecode})
+		if err := lox.Register("explode", func() { panic("boom") }); err != nil {
+			t.Fatal(err)
+		}
+		if err := lox.Eval(context.Background(), script); err != nil {
+			t.Fatalf("bytecode=%v: %v", bytecode, err)
+		}
+		if got, want := stdout.String(), "Native function 'explode' panicked: boom.\n"; got != want {
+			t.Errorf("bytecode=%v: printed %q, want %q", bytecode, got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package golox
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/jordanwebster/golox/ast"
+	"github.com/jordanwebster/golox/loxerror"
+)
+
+// TestResult is the outcome of one test function in a test script.
+type TestResult struct {
+	// Name is the test function, or empty if the script itself couldn't be
+	// read or compiled so no tests ran.
+	Name string
+	// Err is nil if the test passed.
+	Err error
+	// Diagnostics holds the errors that failed the test.
+	Diagnostics *loxerror.Diagnostics
+	// Output is what the script and the test printed.
+	Output string
+}
+
+// RunTests runs the top-level functions whose names start with "test" in
+// the script at path, in the order they are declared. Each test gets a new
+// Runtime, so that one test can't affect another, in which the script is
+// run before the function is called. Scripts can call the assert,
+// assertEqual and assertThrows natives. What they print is kept in each
+// result's Output instead of being written to opts.Stdout.
+func RunTests(ctx context.Context, path string, opts Options) []TestResult {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return []TestResult{{Err: err, Diagnostics: loxerror.NewDiagnostics()}}
+	}
+
+	diagnostics := loxerror.NewDiagnostics()
+	stmts := parse(bytes.NewReader(source), diagnostics)
+	if diagnostics.HasErrors() {
+		return []TestResult{{Err: &CompileError{Errors: diagnostics.Errors()}, Diagnostics: diagnostics}}
+	}
+
+	var results []TestResult
+	for _, stmt := range stmts {
+		function, isFunction := stmt.(*ast.FunctionStmt)
+		if !isFunction || !strings.HasPrefix(function.Name.Lexeme, "test") {
+			continue
+		}
+
+		result := runTest(ctx, path, string(source), function, opts)
+		if _, isCompileError := result.Err.(*CompileError); isCompileError {
+			// The resolver rejected the script, which would fail every test
+			// in the same way.
+			result.Name = ""
+			return []TestResult{result}
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func runTest(ctx context.Context, path string, source string, test *ast.FunctionStmt, opts Options) TestResult {
+	var output bytes.Buffer
+	opts.Stdout = &output
+	runtime := New(opts)
+	if runtime.vm == nil {
+		runtime.interpreter.DefineTestNatives()
+	} else {
+		runtime.vm.DefineTestNatives()
+	}
+
+	err := runtime.EvalFile(ctx, path, source)
+	if err == nil {
+		// The call is placed on the line the test is declared on so that
+		// stack traces point into the script.
+		call := strings.Repeat("\n", test.Name.Line-1) + test.Name.Lexeme + "();"
+		err = runtime.EvalFile(ctx, path, call)
+	}
+
+	return TestResult{Name: test.Name.Lexeme, Err: err, Diagnostics: runtime.Diagnostics(), Output: output.String()}
+}
@@ -0,0 +1,104 @@
+package golox_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanwebster/golox/golox"
+)
+
+const testScript = `
+var runs = 0;
+print "loading";
+
+fun testPasses() {
+  runs = runs + 1;
+  assert(runs == 1, "Each test should see fresh globals.");
+  assertEqual([1, {"a": nil}], [1, {"a": nil}]);
+  assertEqual(assertThrows(() => nil + 1).message, "Operands must be two numbers or two strings.");
+}
+
+fun testCycles() {
+  var a = [];
+  a.push(a);
+  var b = [];
+  b.push(b);
+  assertEqual(a, a);
+  assertEqual(a, b);
+}
+
+fun testFreshGlobals() {
+  runs = runs + 1;
+  assert(runs == 1, "Each test should see fresh globals.");
+}
+
+fun testFailsEqual() {
+  print "comparing";
+  assertEqual(1 + 1, 3);
+}
+
+fun testFailsThrows() {
+  assertThrows(fun () {});
+}
+
+fun helper() {
+  assert(false);
+}
+`
+
+func TestRunTests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example_test.lox")
+	if err := os.WriteFile(path, []byte(testScript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name    string
+		failure string
+		output  string
+	}{
+		{"testPasses", "", "loading\n"},
+		{"testCycles", "", "loading\n"},
+		{"testFreshGlobals", "", "loading\n"},
+		{"testFailsEqual", "Expected 3 but got 2.", "loading\ncomparing\n"},
+		{"testFailsThrows", "Expected function to throw.", "loading\n"},
+	}
+
+	for _, bytecode := range []bool{false, true} {
+		results := golox.RunTests(context.Background(), path, golox.Options{Bytecode: bytecode})
+		if len(results) != len(want) {
+			t.Fatalf("bytecode=%v: got %d results, want %d", bytecode, len(results), len(want))
+		}
+
+		for i, result := range results {
+			if result.Name != want[i].name {
+				t.Errorf("bytecode=%v: result %d is %s, want %s", bytecode, i, result.Name, want[i].name)
+			}
+
+			failure := ""
+			if result.Err != nil {
+				failure = result.Diagnostics.All()[0].Message
+			}
+			if failure != want[i].failure {
+				t.Errorf("bytecode=%v: %s failed with %q, want %q", bytecode, result.Name, failure, want[i].failure)
+			}
+			if result.Output != want[i].output {
+				t.Errorf("bytecode=%v: %s printed %q, want %q", bytecode, result.Name, result.Output, want[i].output)
+			}
+		}
+	}
+}
+
+func TestRunTestsCompileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken_test.lox")
+	if err := os.WriteFile(path, []byte("fun testBroken() { var a = ; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := golox.RunTests(context.Background(), path, golox.Options{})
+	if len(results) != 1 || results[0].Name != "" || results[0].Err == nil {
+		t.Fatalf("got %+v, want a single failure for the script", results)
+	}
+}
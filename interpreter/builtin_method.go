@@ -0,0 +1,28 @@
+package interpreter
+
+// BuiltinMethod is a method of a built-in type, such as push on lists,
+// already bound to the value it was looked up on. Plain Go errors it returns
+// are reported at the call site.
+type BuiltinMethod struct {
+	arity  int
+	method func(arguments []interface{}) (interface{}, error)
+}
+
+func NewBuiltinMethod(arity int, method func(arguments []interface{}) (interface{}, error)) *BuiltinMethod {
+	return &BuiltinMethod{
+		arity:  arity,
+		method: method,
+	}
+}
+
+func (callable *BuiltinMethod) Arity() int {
+	return callable.arity
+}
+
+func (callable *BuiltinMethod) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return callable.method(arguments)
+}
+
+func (callable *BuiltinMethod) String() string {
+	return "<native fn>"
+}
@@ -1,42 +1,147 @@
 package interpreter
 
 import (
+	"context"
 	"fmt"
-	"reflect"
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/jordanwebster/golox/ast"
 	"github.com/jordanwebster/golox/environment"
 	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/native"
 	"github.com/jordanwebster/golox/token"
 )
 
 type Interpreter struct {
+	// builtins holds the natives, which every module can see.
+	builtins *environment.Environment
+	// globals holds the top-level variables of the module being run.
 	globals     *environment.Environment
 	environment *environment.Environment
+	module      *LoxModule
+	modules     map[string]*LoxModule
+	// importing lists the modules whose top-level code is running, outermost
+	// first.
+	importing   []string
+	loader      ModuleLoader
+	locals      map[ast.Expr]int
+	stdout      io.Writer
+	stderr      io.Writer
+	diagnostics *loxerror.Diagnostics
+	ctx         context.Context
+	frames      []callFrame
 }
 
-func NewInterpreter() *Interpreter {
-	globals := environment.NewGlobalEnvironment()
-	globals.Define("clock", &ClockCallable{})
+// framesMax limits how deeply calls can nest, counting the top-level script,
+// so that runaway recursion is a runtime error rather than exhausting the Go
+// stack. It matches the VM's limit.
+const framesMax = 1024
+
+// callFrame is a call to a Lox function that has not yet returned, kept so
+// that runtime errors can show how execution reached them.
+type callFrame struct {
+	function string
+	file     string
+	callSite token.Token
+}
+
+// NewInterpreter creates an interpreter that writes the output of print and
+// write to stdout and that of eprint to stderr.
+func NewInterpreter(stdout io.Writer, stderr io.Writer, diagnostics *loxerror.Diagnostics) *Interpreter {
+	builtins := environment.NewGlobalEnvironment()
+	builtins.Define("clock", &ClockCallable{})
+	builtins.Define("eprint", &EprintCallable{})
+	builtins.Define("write", &WriteCallable{})
+	for _, function := range native.MathFunctions() {
+		builtins.Define(function.Name(), &NativeFunction{function: function})
+	}
+	for name, value := range native.MathConstants {
+		builtins.Define(name, value)
+	}
+	json := native.JSON()
+	builtins.Define(json.Name(), json)
+	main := NewModule("", environment.NewEnvironment(builtins))
 	return &Interpreter{
-		globals:     globals,
-		environment: globals,
+		builtins:    builtins,
+		globals:     main.globals,
+		environment: main.globals,
+		module:      main,
+		modules:     make(map[string]*LoxModule),
+		locals:      make(map[ast.Expr]int),
+		stdout:      stdout,
+		stderr:      stderr,
+		diagnostics: diagnostics,
+		ctx:         context.Background(),
+	}
+}
+
+// DefineNative makes a Go function callable from Lox as a global.
+func (interpreter *Interpreter) DefineNative(function *native.Function) {
+	interpreter.builtins.Define(function.Name(), &NativeFunction{function: function})
+}
+
+// DefineTestNatives defines the assertions available to test scripts.
+func (interpreter *Interpreter) DefineTestNatives() {
+	for _, function := range native.AssertFunctions() {
+		interpreter.DefineNative(function)
 	}
+	interpreter.builtins.Define("assertThrows", &AssertThrowsCallable{})
+}
+
+// DefineFileSystem defines the fs global, which gives scripts access to
+// files.
+func (interpreter *Interpreter) DefineFileSystem() {
+	fs := native.FileSystem()
+	interpreter.builtins.Define(fs.Name(), fs)
+}
+
+// SetModuleLoader sets how import statements find their modules. Without
+// one, imports fail.
+func (interpreter *Interpreter) SetModuleLoader(loader ModuleLoader) {
+	interpreter.loader = loader
+}
+
+// Resolve records the number of environments between a variable reference
+// and its declaration, as computed by the resolver.
+func (interpreter *Interpreter) Resolve(expr ast.Expr, depth int) {
+	interpreter.locals[expr] = depth
 }
 
-func (interpreter *Interpreter) Interpret(statements []ast.Stmt) {
+// Interpret executes the statements in order, stopping at the first runtime
+// error, which is both reported to the diagnostics and returned. Execution
+// is abandoned with the context's error if ctx is cancelled while a loop or
+// function call is running.
+func (interpreter *Interpreter) Interpret(ctx context.Context, statements []ast.Stmt) error {
+	interpreter.ctx = ctx
+	defer func() {
+		interpreter.ctx = context.Background()
+	}()
+
 	for _, stmt := range statements {
 		err := interpreter.execute(stmt)
+		if thrown, isThrow := err.(*Throw); isThrow {
+			err = thrown.err
+		}
 		if err != nil {
-			switch err.(type) {
+			switch runtimeError := err.(type) {
 			case *loxerror.RuntimeError:
-				loxerror.ReportRuntimeError(err.(*loxerror.RuntimeError))
+				if runtimeError.Trace() == nil {
+					runtimeError.WithTrace(interpreter.stackTrace(runtimeError.Token().Line))
+				}
+				interpreter.diagnostics.Report(err)
+				return err
 			default:
+				if err == ctx.Err() {
+					return err
+				}
 				panic(err)
 			}
 		}
 	}
+
+	return nil
 }
 
 func (interpreter *Interpreter) VisitLiteralExpr(expr *ast.LiteralExpr) (interface{}, error) {
@@ -64,13 +169,247 @@ func (interpreter *Interpreter) VisitCallExpr(expr *ast.CallExpr) (interface{},
 
 	function, ok := callee.(LoxCallable)
 	if !ok {
-		return nil, loxerror.NewRuntimeError(expr.Paren, "Can only calls functions and classes.")
+		return nil, loxerror.NewRuntimeError(expr.Paren, "Can only call functions and classes.")
 	}
 
-	if len(arguments) != function.Arity() {
+	if variadic, isVariadic := function.(VariadicCallable); isVariadic && variadic.IsVariadic() {
+		if len(arguments) < function.Arity() {
+			return nil, loxerror.NewRuntimeError(expr.Paren, fmt.Sprintf("Expected at least %d arguments but got %d.", function.Arity(), len(arguments)))
+		}
+	} else if len(arguments) != function.Arity() {
 		return nil, loxerror.NewRuntimeError(expr.Paren, fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments)))
 	}
-	return function.Call(interpreter, arguments)
+
+	if frame, isTraced := frameFor(function); isTraced {
+		if len(interpreter.frames) >= framesMax-1 {
+			return nil, loxerror.NewRuntimeError(expr.Paren, "Stack overflow.")
+		}
+
+		frame.callSite = expr.Paren
+		interpreter.frames = append(interpreter.frames, frame)
+		defer func() {
+			interpreter.frames = interpreter.frames[:len(interpreter.frames)-1]
+		}()
+	}
+
+	result, err := function.Call(interpreter, arguments)
+	if runtimeError, isRuntimeError := err.(*loxerror.RuntimeError); isRuntimeError && runtimeError.Trace() == nil {
+		// Capture the trace while the frames the error passed through are
+		// still on the stack.
+		runtimeError.WithTrace(interpreter.stackTrace(runtimeError.Token().Line))
+	}
+	switch function.(type) {
+	case *NativeFunction, *BuiltinMethod, *AssertThrowsCallable:
+		// Go errors carry no position so report them at the call site.
+		if _, isRuntimeError := err.(*loxerror.RuntimeError); err != nil && !isRuntimeError {
+			return nil, loxerror.NewRuntimeError(expr.Paren, err.Error())
+		}
+	}
+
+	return result, err
+}
+
+func (interpreter *Interpreter) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	object, err := interpreter.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance, isInstance := object.(*LoxInstance); isInstance {
+		return instance.Get(expr.Name)
+	}
+
+	if getter, hasProperties := object.(PropertyGetter); hasProperties {
+		return getter.GetProperty(expr.Name)
+	}
+
+	if s, isString := object.(string); isString {
+		if method, isPresent := native.StringMethod(s, expr.Name.Lexeme); isPresent {
+			return &NativeFunction{function: method}, nil
+		}
+		return nil, loxerror.NewRuntimeError(expr.Name, fmt.Sprintf("Undefined property '%s'.", expr.Name.Lexeme))
+	}
+
+	if object, isObject := object.(native.Object); isObject {
+		if method, isPresent := object.Method(expr.Name.Lexeme); isPresent {
+			return &NativeFunction{function: method}, nil
+		}
+		return nil, loxerror.NewRuntimeError(expr.Name, fmt.Sprintf("Undefined property '%s'.", expr.Name.Lexeme))
+	}
+
+	return nil, loxerror.NewRuntimeError(expr.Name, "Only instances have properties.")
+}
+
+func (interpreter *Interpreter) VisitListExpr(expr *ast.ListExpr) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := interpreter.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+
+	return NewList(elements), nil
+}
+
+func (interpreter *Interpreter) VisitMapExpr(expr *ast.MapExpr) (interface{}, error) {
+	m := NewMap()
+	for i := range expr.Keys {
+		key, err := interpreter.evaluate(expr.Keys[i])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := interpreter.evaluate(expr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.SetIndex(key, value); err != nil {
+			return nil, loxerror.NewRuntimeError(expr.Brace, err.Error())
+		}
+	}
+
+	return m, nil
+}
+
+func (interpreter *Interpreter) VisitInterpolationExpr(expr *ast.InterpolationExpr) (interface{}, error) {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		value, err := interpreter.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(stringify(value))
+	}
+
+	return builder.String(), nil
+}
+
+func (interpreter *Interpreter) VisitIndexExpr(expr *ast.IndexExpr) (interface{}, error) {
+	object, err := interpreter.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := interpreter.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	container, isIndexable := object.(Indexable)
+	if !isIndexable {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, "Only lists and maps can be indexed.")
+	}
+
+	value, err := container.Index(index)
+	if err != nil {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, err.Error())
+	}
+
+	return value, nil
+}
+
+func (interpreter *Interpreter) VisitIndexSetExpr(expr *ast.IndexSetExpr) (interface{}, error) {
+	object, err := interpreter.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := interpreter.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := interpreter.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	container, isIndexable := object.(Indexable)
+	if !isIndexable {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, "Only lists and maps can be indexed.")
+	}
+
+	if err := container.SetIndex(index, value); err != nil {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, err.Error())
+	}
+
+	return value, nil
+}
+
+func (interpreter *Interpreter) VisitSliceExpr(expr *ast.SliceExpr) (interface{}, error) {
+	object, err := interpreter.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var start, end interface{}
+	if expr.Start != nil {
+		if start, err = interpreter.evaluate(expr.Start); err != nil {
+			return nil, err
+		}
+	}
+	if expr.End != nil {
+		if end, err = interpreter.evaluate(expr.End); err != nil {
+			return nil, err
+		}
+	}
+
+	list, isList := object.(*LoxList)
+	if !isList {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, "Only lists can be sliced.")
+	}
+
+	slice, err := list.Slice(start, end)
+	if err != nil {
+		return nil, loxerror.NewRuntimeError(expr.Bracket, err.Error())
+	}
+
+	return slice, nil
+}
+
+func (interpreter *Interpreter) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	object, err := interpreter.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	// The value is evaluated before the object is checked, as the VM does
+	// and as index assignments do.
+	value, err := interpreter.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, isInstance := object.(*LoxInstance)
+	if !isInstance {
+		return nil, loxerror.NewRuntimeError(expr.Name, "Only instances have fields.")
+	}
+
+	instance.Set(expr.Name, value)
+	return value, nil
+}
+
+func (interpreter *Interpreter) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	distance := interpreter.locals[expr]
+	superclass := interpreter.environment.GetAt(distance, "super").(*LoxClass)
+
+	// The environment binding "this" always sits directly inside the one
+	// binding "super".
+	instance := interpreter.environment.GetAt(distance-1, "this").(*LoxInstance)
+
+	method := superclass.FindMethod(expr.Method.Lexeme)
+	if method == nil {
+		return nil, loxerror.NewRuntimeError(expr.Method, fmt.Sprintf("Undefined property '%s'.", expr.Method.Lexeme))
+	}
+
+	return method.Bind(instance), nil
+}
+
+func (interpreter *Interpreter) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return interpreter.lookUpVariable(expr.Keyword, expr)
 }
 
 func (interpreter *Interpreter) VisitUnaryExpr(expr *ast.UnaryExpr) (interface{}, error) {
@@ -131,7 +470,7 @@ func (interpreter *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (interface
 		}
 		return left.(float64) <= right.(float64), nil
 	case token.BANG_EQUAL:
-		return isEqual(left, right), nil
+		return !isEqual(left, right), nil
 	case token.EQUAL_EQUAL:
 		return isEqual(left, right), nil
 	case token.MINUS:
@@ -173,7 +512,7 @@ func (interpreter *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (interface
 }
 
 func (interpreter *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) (interface{}, error) {
-	return interpreter.environment.Get(expr.Name)
+	return interpreter.lookUpVariable(expr.Name, expr)
 }
 
 func (interpreter *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) (interface{}, error) {
@@ -182,7 +521,12 @@ func (interpreter *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) (interface
 		return nil, err
 	}
 
-	if err = interpreter.environment.Assign(expr.Name, value); err != nil {
+	if distance, isLocal := interpreter.locals[expr]; isLocal {
+		interpreter.environment.AssignAt(distance, expr.Name, value)
+		return value, nil
+	}
+
+	if err = interpreter.globals.Assign(expr.Name, value); err != nil {
 		return nil, err
 	} else {
 		return value, nil
@@ -218,16 +562,56 @@ func (interpreter *Interpreter) VisitPrintStmt(stmt *ast.PrintStmt) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(stringify(value))
+	fmt.Fprintln(interpreter.stdout, stringify(value))
 	return nil
 }
 
 func (interpreter *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
-	function := NewFunction(stmt, interpreter.environment)
+	function := NewFunction(stmt, interpreter.environment, interpreter.module, false)
 	interpreter.environment.Define(stmt.Name.Lexeme, function)
 	return nil
 }
 
+func (interpreter *Interpreter) VisitFunctionExpr(expr *ast.FunctionExpr) (interface{}, error) {
+	return NewFunction(expr.Declaration, interpreter.environment, interpreter.module, false), nil
+}
+
+func (interpreter *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) error {
+	var superclass *LoxClass = nil
+	if stmt.Superclass != nil {
+		value, err := interpreter.evaluate(stmt.Superclass)
+		if err != nil {
+			return err
+		}
+
+		class, isClass := value.(*LoxClass)
+		if !isClass {
+			return loxerror.NewRuntimeError(stmt.Superclass.Name, "Superclass must be a class.")
+		}
+		superclass = class
+	}
+
+	interpreter.environment.Define(stmt.Name.Lexeme, nil)
+
+	if superclass != nil {
+		interpreter.environment = environment.NewEnvironment(interpreter.environment)
+		interpreter.environment.Define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range stmt.Methods {
+		methods[method.Name.Lexeme] = NewFunction(method, interpreter.environment, interpreter.module, method.Name.Lexeme == "init")
+	}
+
+	class := NewClass(stmt.Name.Lexeme, superclass, methods)
+
+	if superclass != nil {
+		interpreter.environment = interpreter.environment.Enclosing()
+	}
+
+	return interpreter.environment.Assign(stmt.Name, class)
+}
+
 func (interpreter *Interpreter) VisitReturnStmt(stmt *ast.ReturnStmt) error {
 	var value interface{} = nil
 	var err error = nil
@@ -243,24 +627,174 @@ func (interpreter *Interpreter) VisitReturnStmt(stmt *ast.ReturnStmt) error {
 
 func (interpreter *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) error {
 	for {
+		if err := interpreter.ctx.Err(); err != nil {
+			return err
+		}
+
 		shouldExecute, err := interpreter.evaluate(stmt.Condition)
 		if err != nil {
 			return err
 		}
 
-		if isTruthy(shouldExecute) {
-			err = interpreter.execute(stmt.Body)
-			if err != nil {
+		if !isTruthy(shouldExecute) {
+			break
+		}
+
+		err = interpreter.execute(stmt.Body)
+		switch err.(type) {
+		case nil, *Continue:
+		case *Break:
+			return nil
+		default:
+			return err
+		}
+
+		if stmt.Increment != nil {
+			if _, err := interpreter.evaluate(stmt.Increment); err != nil {
 				return err
 			}
-		} else {
-			break
 		}
 	}
 
 	return nil
 }
 
+func (interpreter *Interpreter) VisitBreakStmt(stmt *ast.BreakStmt) error {
+	return &Break{}
+}
+
+func (interpreter *Interpreter) VisitContinueStmt(stmt *ast.ContinueStmt) error {
+	return &Continue{}
+}
+
+func (interpreter *Interpreter) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	value, err := interpreter.evaluate(stmt.Value)
+	if err != nil {
+		return err
+	}
+
+	// Rethrowing a caught runtime error keeps its original location.
+	if loxError, isError := value.(*LoxError); isError {
+		return &Throw{Value: value, err: loxError.err}
+	}
+
+	runtimeError := loxerror.NewRuntimeError(stmt.Keyword, "Uncaught exception: "+stringify(value))
+	runtimeError.WithTrace(interpreter.stackTrace(stmt.Keyword.Line))
+	return &Throw{Value: value, err: runtimeError}
+}
+
+func (interpreter *Interpreter) VisitTryStmt(stmt *ast.TryStmt) error {
+	err := interpreter.executeBlock(stmt.Body, environment.NewEnvironment(interpreter.environment))
+	if err != nil && stmt.CatchBody != nil {
+		if exception, isCaught := interpreter.catch(err); isCaught {
+			env := environment.NewEnvironment(interpreter.environment)
+			env.Define(stmt.CatchName.Lexeme, exception)
+			err = interpreter.executeBlock(stmt.CatchBody, env)
+		}
+	}
+
+	if stmt.FinallyBody != nil {
+		// A return, break or throw in the finally block replaces whatever
+		// was already unwinding.
+		finallyErr := interpreter.executeBlock(stmt.FinallyBody, environment.NewEnvironment(interpreter.environment))
+		if finallyErr != nil {
+			return finallyErr
+		}
+	}
+
+	return err
+}
+
+// catch returns the value a catch clause receives for err. Only thrown
+// values and runtime errors can be caught; returns and loop control pass
+// straight through, as does cancellation.
+func (interpreter *Interpreter) catch(err error) (interface{}, bool) {
+	switch err := err.(type) {
+	case *Throw:
+		return err.Value, true
+	case *loxerror.RuntimeError:
+		if err.Trace() == nil {
+			err.WithTrace(interpreter.stackTrace(err.Token().Line))
+		}
+		return NewLoxError(err), true
+	default:
+		return nil, false
+	}
+}
+
+func (interpreter *Interpreter) VisitImportStmt(stmt *ast.ImportStmt) error {
+	module, err := interpreter.importModule(stmt)
+	if err != nil {
+		return err
+	}
+
+	if stmt.Names == nil {
+		interpreter.environment.Define(stmt.Alias.Lexeme, module)
+		return nil
+	}
+
+	for _, name := range stmt.Names {
+		value, err := module.GetProperty(name)
+		if err != nil {
+			return err
+		}
+		interpreter.environment.Define(name.Lexeme, value)
+	}
+	return nil
+}
+
+// importModule runs a module's top-level code the first time it is
+// imported, in its own globals, and returns the cached module after that.
+func (interpreter *Interpreter) importModule(stmt *ast.ImportStmt) (*LoxModule, error) {
+	if interpreter.loader == nil {
+		return nil, loxerror.NewRuntimeError(stmt.Keyword, "Imports are not supported here.")
+	}
+
+	file, err := interpreter.loader.Find(interpreter.module.file, stmt.Path.Literal.(string))
+	if err != nil {
+		return nil, loxerror.NewRuntimeError(stmt.Path, err.Error())
+	}
+
+	if module, isLoaded := interpreter.modules[file]; isLoaded {
+		return module, nil
+	}
+
+	for i, importing := range interpreter.importing {
+		if importing == file {
+			cycle := append(append([]string(nil), interpreter.importing[i:]...), file)
+			message := fmt.Sprintf("Import cycle: %s.", strings.Join(cycle, " -> "))
+			return nil, loxerror.NewRuntimeError(stmt.Path, message)
+		}
+	}
+
+	stmts, err := interpreter.loader.Load(file)
+	if err != nil {
+		return nil, loxerror.NewRuntimeError(stmt.Path, err.Error())
+	}
+
+	module := NewModule(file, environment.NewEnvironment(interpreter.builtins))
+	previousModule, previousGlobals := interpreter.module, interpreter.globals
+	interpreter.module, interpreter.globals = module, module.globals
+	interpreter.importing = append(interpreter.importing, file)
+	interpreter.frames = append(interpreter.frames, callFrame{function: "<module>", file: file, callSite: stmt.Keyword})
+	defer func() {
+		interpreter.module, interpreter.globals = previousModule, previousGlobals
+		interpreter.importing = interpreter.importing[:len(interpreter.importing)-1]
+		interpreter.frames = interpreter.frames[:len(interpreter.frames)-1]
+	}()
+
+	err = interpreter.executeBlock(stmts, module.globals)
+	if runtimeError, isRuntimeError := err.(*loxerror.RuntimeError); isRuntimeError && runtimeError.Trace() == nil {
+		runtimeError.WithTrace(interpreter.stackTrace(runtimeError.Token().Line))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	interpreter.modules[file] = module
+	return module, nil
+}
+
 func (interpreter *Interpreter) VisitBlockStmt(stmt *ast.BlockStmt) error {
 	return interpreter.executeBlock(stmt.Statements, environment.NewEnvironment(interpreter.environment))
 }
@@ -294,6 +828,14 @@ func (interpreter *Interpreter) VisitIfStmt(stmt *ast.IfStmt) error {
 	return nil
 }
 
+func (interpreter *Interpreter) lookUpVariable(name token.Token, expr ast.Expr) (interface{}, error) {
+	if distance, isLocal := interpreter.locals[expr]; isLocal {
+		return interpreter.environment.GetAt(distance, name.Lexeme), nil
+	}
+
+	return interpreter.globals.Get(name)
+}
+
 func (interpreter *Interpreter) evaluate(expr ast.Expr) (interface{}, error) {
 	return expr.Accept(interpreter)
 }
@@ -338,7 +880,9 @@ func isEqual(a interface{}, b interface{}) bool {
 		return false
 	}
 
-	return reflect.DeepEqual(a, b)
+	// Objects such as instances, lists and maps are compared by identity,
+	// not by the values they hold.
+	return a == b
 }
 
 func checkNumberOperand(operator token.Token, operand interface{}) error {
@@ -371,3 +915,34 @@ func stringify(object interface{}) string {
 
 	return fmt.Sprintf("%v", object)
 }
+
+// frameFor describes how a call shows up in stack traces. Natives report
+// their errors at the call site so they don't get a frame of their own.
+func frameFor(callee LoxCallable) (callFrame, bool) {
+	switch callee := callee.(type) {
+	case *LoxFunction:
+		return callFrame{function: callee.declaration.Name.Lexeme, file: callee.module.file}, true
+	case *LoxClass:
+		// Calling a class runs its initializer.
+		frame := callFrame{function: "init"}
+		if initializer := callee.FindMethod("init"); initializer != nil {
+			frame.file = initializer.module.file
+		}
+		return frame, true
+	default:
+		return callFrame{}, false
+	}
+}
+
+// stackTrace lists the active calls, innermost first, given the line being
+// executed in the innermost one.
+func (interpreter *Interpreter) stackTrace(line int) []loxerror.Frame {
+	trace := make([]loxerror.Frame, 0, len(interpreter.frames)+1)
+	for i := len(interpreter.frames) - 1; i >= 0; i-- {
+		frame := interpreter.frames[i]
+		trace = append(trace, loxerror.Frame{Function: frame.function, File: frame.file, Line: line})
+		line = frame.callSite.Line
+	}
+
+	return append(trace, loxerror.Frame{Function: "<script>", Line: line})
+}
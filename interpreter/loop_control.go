@@ -0,0 +1,15 @@
+package interpreter
+
+// Break and Continue unwind the statements of a loop body in the same way
+// that Return unwinds a function body.
+type Break struct{}
+
+func (b *Break) Error() string {
+	return "Break statement."
+}
+
+type Continue struct{}
+
+func (c *Continue) Error() string {
+	return "Continue statement."
+}
@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jordanwebster/golox/ast"
@@ -12,6 +14,13 @@ type LoxCallable interface {
 	Arity() int
 }
 
+// VariadicCallable is implemented by callables that accept any number of
+// arguments beyond their arity.
+type VariadicCallable interface {
+	LoxCallable
+	IsVariadic() bool
+}
+
 type ClockCallable struct{}
 
 func (callable *ClockCallable) Arity() int {
@@ -19,36 +28,139 @@ func (callable *ClockCallable) Arity() int {
 }
 
 func (callable *ClockCallable) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
-	return (time.Now().UnixMilli() / 1000.0), nil
+	return float64(time.Now().UnixNano()) / float64(time.Second), nil
+}
+
+func (callable *ClockCallable) String() string {
+	return "<native fn>"
+}
+
+// EprintCallable prints its argument to stderr, as print does to stdout.
+type EprintCallable struct{}
+
+func (callable *EprintCallable) Arity() int {
+	return 1
+}
+
+func (callable *EprintCallable) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	fmt.Fprintln(interpreter.stderr, stringify(arguments[0]))
+	return nil, nil
+}
+
+func (callable *EprintCallable) String() string {
+	return "<native fn>"
+}
+
+// WriteCallable prints its argument to stdout without a newline.
+type WriteCallable struct{}
+
+func (callable *WriteCallable) Arity() int {
+	return 1
+}
+
+func (callable *WriteCallable) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	fmt.Fprint(interpreter.stdout, stringify(arguments[0]))
+	return nil, nil
+}
+
+func (callable *WriteCallable) String() string {
+	return "<native fn>"
+}
+
+// AssertThrowsCallable calls a function that takes no arguments and fails
+// unless it throws, returning what was thrown so that tests can inspect it.
+type AssertThrowsCallable struct{}
+
+func (callable *AssertThrowsCallable) Arity() int {
+	return 1
+}
+
+func (callable *AssertThrowsCallable) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	function, isFunction := arguments[0].(*LoxFunction)
+	if !isFunction {
+		return nil, errors.New("Argument to 'assertThrows' must be a function.")
+	}
+	if function.Arity() != 0 {
+		return nil, errors.New("Function passed to 'assertThrows' must not take arguments.")
+	}
+
+	_, err := function.Call(interpreter, nil)
+	if err == nil {
+		return nil, errors.New("Expected function to throw.")
+	}
+	if thrown, isCaught := interpreter.catch(err); isCaught {
+		return thrown, nil
+	}
+	return nil, err
+}
+
+func (callable *AssertThrowsCallable) String() string {
+	return "<native fn>"
 }
 
 type LoxFunction struct {
 	declaration *ast.FunctionStmt
 	closure     *environment.Environment
+	// module is the module the function was declared in, whose globals it
+	// sees when called.
+	module        *LoxModule
+	isInitializer bool
 }
 
-func NewFunction(declaration *ast.FunctionStmt, closure *environment.Environment) LoxCallable {
+func NewFunction(declaration *ast.FunctionStmt, closure *environment.Environment, module *LoxModule, isInitializer bool) *LoxFunction {
 	return &LoxFunction{
 		declaration,
 		closure,
+		module,
+		isInitializer,
 	}
 }
 
+// Bind returns a copy of the method whose closure defines "this" as the given
+// instance.
+func (function *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
+	env := environment.NewEnvironment(function.closure)
+	env.Define("this", instance)
+	return NewFunction(function.declaration, env, function.module, function.isInitializer)
+}
+
 func (function *LoxFunction) Arity() int {
 	return len(function.declaration.Parameters)
 }
 
 func (function *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := interpreter.ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	env := environment.NewEnvironment(function.closure)
 	for i, param := range function.declaration.Parameters {
 		env.Define(param.Lexeme, arguments[i])
 	}
 
+	previousModule, previousGlobals := interpreter.module, interpreter.globals
+	interpreter.module, interpreter.globals = function.module, function.module.globals
+	defer func() {
+		interpreter.module, interpreter.globals = previousModule, previousGlobals
+	}()
+
 	err := interpreter.executeBlock(function.declaration.Body, env)
 	switch v := err.(type) {
 	case *Return:
+		if function.isInitializer {
+			return function.closure.GetAt(0, "this"), nil
+		}
 		return v.Value, nil
+	case nil:
+		if function.isInitializer {
+			return function.closure.GetAt(0, "this"), nil
+		}
+		return nil, nil
 	default:
 		return nil, err
 	}
 }
+
+func (function *LoxFunction) String() string {
+	return fmt.Sprintf("<fn %s>", function.declaration.Name.Lexeme)
+}
@@ -0,0 +1,51 @@
+package interpreter
+
+type LoxClass struct {
+	Name       string
+	superclass *LoxClass
+	methods    map[string]*LoxFunction
+}
+
+func NewClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{
+		Name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}
+}
+
+func (class *LoxClass) FindMethod(name string) *LoxFunction {
+	if method, isPresent := class.methods[name]; isPresent {
+		return method
+	}
+
+	if class.superclass != nil {
+		return class.superclass.FindMethod(name)
+	}
+
+	return nil
+}
+
+func (class *LoxClass) Arity() int {
+	if initializer := class.FindMethod("init"); initializer != nil {
+		return initializer.Arity()
+	}
+
+	return 0
+}
+
+func (class *LoxClass) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance := NewInstance(class)
+	if initializer := class.FindMethod("init"); initializer != nil {
+		_, err := initializer.Bind(instance).Call(interpreter, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (class *LoxClass) String() string {
+	return class.Name
+}
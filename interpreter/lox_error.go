@@ -0,0 +1,39 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+// LoxError is the value a catch clause receives when it catches a runtime
+// error raised by the interpreter rather than by a throw statement.
+type LoxError struct {
+	err *loxerror.RuntimeError
+}
+
+func NewLoxError(err *loxerror.RuntimeError) *LoxError {
+	return &LoxError{err: err}
+}
+
+func (loxError *LoxError) GetProperty(name token.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "message":
+		return loxError.err.Message(), nil
+	case "line":
+		return float64(loxError.err.Token().Line), nil
+	case "trace":
+		trace := make([]interface{}, len(loxError.err.Trace()))
+		for i, frame := range loxError.err.Trace() {
+			trace[i] = frame.String()
+		}
+		return NewList(trace), nil
+	}
+
+	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+func (loxError *LoxError) String() string {
+	return loxError.err.Message()
+}
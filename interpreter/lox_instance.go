@@ -0,0 +1,48 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+// PropertyGetter is implemented by built-in types, such as lists, whose
+// methods are reached with the same dot syntax as those of instances.
+type PropertyGetter interface {
+	GetProperty(name token.Token) (interface{}, error)
+}
+
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]interface{}
+}
+
+func NewInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		class:  class,
+		fields: make(map[string]interface{}),
+	}
+}
+
+func (instance *LoxInstance) Get(name token.Token) (interface{}, error) {
+	if value, isPresent := instance.fields[name.Lexeme]; isPresent {
+		return value, nil
+	}
+
+	// Fields shadow methods so only fall back to the class once we know
+	// there is no field with this name.
+	if method := instance.class.FindMethod(name.Lexeme); method != nil {
+		return method.Bind(instance), nil
+	}
+
+	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+func (instance *LoxInstance) Set(name token.Token, value interface{}) {
+	instance.fields[name.Lexeme] = value
+}
+
+func (instance *LoxInstance) String() string {
+	return instance.class.Name + " instance"
+}
@@ -0,0 +1,206 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+// Indexable is implemented by the containers that support xs[i] and
+// xs[i] = v.
+type Indexable interface {
+	Index(index interface{}) (interface{}, error)
+	SetIndex(index interface{}, value interface{}) error
+}
+
+type LoxList struct {
+	elements []interface{}
+}
+
+func NewList(elements []interface{}) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+func (list *LoxList) Len() int {
+	return len(list.elements)
+}
+
+// At returns the element at a position known to be in range.
+func (list *LoxList) At(i int) interface{} {
+	return list.elements[i]
+}
+
+// Index returns the element at index, counting back from the end if it is
+// negative.
+func (list *LoxList) Index(index interface{}) (interface{}, error) {
+	i, err := list.position(index, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.elements[i], nil
+}
+
+func (list *LoxList) SetIndex(index interface{}, value interface{}) error {
+	i, err := list.position(index, false)
+	if err != nil {
+		return err
+	}
+
+	list.elements[i] = value
+	return nil
+}
+
+// Slice copies the elements from start up to but not including end. Either
+// bound may be nil to mean the start or end of the list, and bounds outside
+// the list are clamped to it.
+func (list *LoxList) Slice(start interface{}, end interface{}) (*LoxList, error) {
+	from, err := list.bound(start, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := list.bound(end, len(list.elements))
+	if err != nil {
+		return nil, err
+	}
+
+	if to < from {
+		to = from
+	}
+
+	elements := make([]interface{}, to-from)
+	copy(elements, list.elements[from:to])
+	return NewList(elements), nil
+}
+
+func (list *LoxList) GetProperty(name token.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "len":
+		return NewBuiltinMethod(0, func(arguments []interface{}) (interface{}, error) {
+			return float64(len(list.elements)), nil
+		}), nil
+	case "push":
+		return NewBuiltinMethod(1, func(arguments []interface{}) (interface{}, error) {
+			list.elements = append(list.elements, arguments[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return NewBuiltinMethod(0, func(arguments []interface{}) (interface{}, error) {
+			if len(list.elements) == 0 {
+				return nil, errors.New("Can't pop from an empty list.")
+			}
+
+			last := list.elements[len(list.elements)-1]
+			list.elements = list.elements[:len(list.elements)-1]
+			return last, nil
+		}), nil
+	case "insert":
+		return NewBuiltinMethod(2, func(arguments []interface{}) (interface{}, error) {
+			// Inserting at the length appends, so it is a valid position.
+			i, err := list.position(arguments[0], true)
+			if err != nil {
+				return nil, err
+			}
+
+			list.elements = append(list.elements, nil)
+			copy(list.elements[i+1:], list.elements[i:])
+			list.elements[i] = arguments[1]
+			return nil, nil
+		}), nil
+	}
+
+	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+func (list *LoxList) String() string {
+	return repr(list, make(map[interface{}]bool))
+}
+
+func (list *LoxList) position(index interface{}, allowEnd bool) (int, error) {
+	number, isNumber := index.(float64)
+	if !isNumber || number != math.Trunc(number) {
+		return 0, errors.New("List index must be an integer.")
+	}
+
+	// The index is checked before it is converted, as a number too large for
+	// an int would otherwise wrap around.
+	i := number
+	if i < 0 {
+		i += float64(len(list.elements))
+	}
+
+	limit := len(list.elements)
+	if allowEnd {
+		limit++
+	}
+	if i < 0 || i >= float64(limit) {
+		return 0, fmt.Errorf("List index %.0f is out of range for length %d.", number, len(list.elements))
+	}
+
+	return int(i), nil
+}
+
+func (list *LoxList) bound(value interface{}, fallback int) (int, error) {
+	if value == nil {
+		return fallback, nil
+	}
+
+	number, isNumber := value.(float64)
+	if !isNumber || number != math.Trunc(number) {
+		return 0, errors.New("Slice bounds must be integers.")
+	}
+
+	i := number
+	if i < 0 {
+		i += float64(len(list.elements))
+	}
+
+	if i < 0 {
+		return 0, nil
+	} else if i > float64(len(list.elements)) {
+		return len(list.elements), nil
+	}
+
+	return int(i), nil
+}
+
+// repr formats a value as it appears inside a container, where strings are
+// quoted. Containers already being printed are shown as "[...]" or "{...}"
+// so that a list holding itself doesn't recurse forever.
+func repr(value interface{}, seen map[interface{}]bool) string {
+	switch value := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case *LoxList:
+		if seen[value] {
+			return "[...]"
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		elements := make([]string, len(value.elements))
+		for i, element := range value.elements {
+			elements[i] = repr(element, seen)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *LoxMap:
+		if seen[value] {
+			return "{...}"
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		entries := make([]string, len(value.keys))
+		for i, key := range value.keys {
+			entries[i] = repr(key, seen) + ": " + repr(value.values[key], seen)
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	default:
+		return stringify(value)
+	}
+}
@@ -0,0 +1,131 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/native"
+	"github.com/jordanwebster/golox/token"
+)
+
+// LoxMap is a dictionary that remembers the order keys were first added
+// in. Keys are restricted to nil, booleans, numbers and strings, which Go
+// compares the same way isEqual does, so they can be used as Go map keys
+// directly.
+type LoxMap struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func NewMap() *LoxMap {
+	return &LoxMap{values: make(map[interface{}]interface{})}
+}
+
+func (m *LoxMap) Index(key interface{}) (interface{}, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	value, isPresent := m.values[key]
+	if !isPresent {
+		return nil, fmt.Errorf("Undefined key %s.", repr(key, nil))
+	}
+
+	return value, nil
+}
+
+func (m *LoxMap) SetIndex(key interface{}, value interface{}) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	if _, isPresent := m.values[key]; !isPresent {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *LoxMap) Entries() []native.Entry {
+	entries := make([]native.Entry, len(m.keys))
+	for i, key := range m.keys {
+		entries[i] = native.Entry{Key: key, Value: m.values[key]}
+	}
+	return entries
+}
+
+func (m *LoxMap) GetProperty(name token.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "len":
+		return NewBuiltinMethod(0, func(arguments []interface{}) (interface{}, error) {
+			return float64(len(m.keys)), nil
+		}), nil
+	case "keys":
+		return NewBuiltinMethod(0, func(arguments []interface{}) (interface{}, error) {
+			return NewList(append([]interface{}(nil), m.keys...)), nil
+		}), nil
+	case "values":
+		return NewBuiltinMethod(0, func(arguments []interface{}) (interface{}, error) {
+			values := make([]interface{}, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.values[key]
+			}
+			return NewList(values), nil
+		}), nil
+	case "has":
+		return NewBuiltinMethod(1, func(arguments []interface{}) (interface{}, error) {
+			if err := checkKey(arguments[0]); err != nil {
+				return nil, err
+			}
+
+			_, isPresent := m.values[arguments[0]]
+			return isPresent, nil
+		}), nil
+	case "remove":
+		// remove returns the value that was removed, or nil if the key was
+		// not present.
+		return NewBuiltinMethod(1, func(arguments []interface{}) (interface{}, error) {
+			key := arguments[0]
+			if err := checkKey(key); err != nil {
+				return nil, err
+			}
+
+			value, isPresent := m.values[key]
+			if !isPresent {
+				return nil, nil
+			}
+
+			delete(m.values, key)
+			for i := range m.keys {
+				if m.keys[i] == key {
+					m.keys = append(m.keys[:i], m.keys[i+1:]...)
+					break
+				}
+			}
+			return value, nil
+		}), nil
+	}
+
+	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme))
+}
+
+func (m *LoxMap) String() string {
+	return repr(m, make(map[interface{}]bool))
+}
+
+func checkKey(key interface{}) error {
+	switch key := key.(type) {
+	case float64:
+		if math.IsNaN(key) {
+			// NaN isn't equal to itself, so it could never be found again.
+			return errors.New("Map keys can't be NaN.")
+		}
+		return nil
+	case nil, bool, string:
+		return nil
+	default:
+		return errors.New("Map keys must be strings, numbers, booleans or nil.")
+	}
+}
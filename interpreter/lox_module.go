@@ -0,0 +1,51 @@
+package interpreter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordanwebster/golox/ast"
+	"github.com/jordanwebster/golox/environment"
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+// ModuleLoader finds and parses the files named by import statements.
+type ModuleLoader interface {
+	// Find returns the file that path refers to when it is imported from
+	// importer, which is empty for the main script.
+	Find(importer string, path string) (string, error)
+	// Load parses and resolves the module in file.
+	Load(file string) ([]ast.Stmt, error)
+}
+
+// LoxModule is an imported file. Its top-level variables are exported as
+// properties, except for those whose names start with an underscore.
+type LoxModule struct {
+	Name    string
+	file    string
+	globals *environment.Environment
+}
+
+func NewModule(file string, globals *environment.Environment) *LoxModule {
+	return &LoxModule{
+		Name:    strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+		file:    file,
+		globals: globals,
+	}
+}
+
+func (module *LoxModule) GetProperty(name token.Token) (interface{}, error) {
+	if !strings.HasPrefix(name.Lexeme, "_") {
+		if value, isPresent := module.globals.Lookup(name.Lexeme); isPresent {
+			return value, nil
+		}
+	}
+
+	return nil, loxerror.NewRuntimeError(name, fmt.Sprintf("Module '%s' has no export '%s'.", module.Name, name.Lexeme))
+}
+
+func (module *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", module.Name)
+}
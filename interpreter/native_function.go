@@ -0,0 +1,71 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/jordanwebster/golox/native"
+)
+
+// NativeFunction exposes a Go function registered through DefineNative.
+type NativeFunction struct {
+	function *native.Function
+}
+
+func (callable *NativeFunction) Arity() int {
+	return callable.function.Arity()
+}
+
+func (callable *NativeFunction) IsVariadic() bool {
+	return callable.function.IsVariadic()
+}
+
+func (callable *NativeFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	result, err := callable.function.Call(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	value, isValid := fromNative(result)
+	if !isValid {
+		return nil, fmt.Errorf("Native function '%s' returned an unsupported %T.", callable.function.Name(), result)
+	}
+	return value, nil
+}
+
+func (callable *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// fromNative converts the slices and ordered maps natives return into lists
+// and maps. It reports false if the value has no Lox representation.
+func fromNative(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case nil, bool, float64, string:
+		return value, true
+	case LoxCallable, *LoxInstance, *LoxList, *LoxMap, *LoxError, *LoxModule, native.Object:
+		// Values a native was passed as interface{} and returned unchanged.
+		return value, true
+	case []interface{}:
+		elements := make([]interface{}, len(value))
+		for i, element := range value {
+			converted, isValid := fromNative(element)
+			if !isValid {
+				return nil, false
+			}
+			elements[i] = converted
+		}
+		return NewList(elements), true
+	case *native.OrderedMap:
+		m := NewMap()
+		for _, entry := range value.Entries() {
+			key, isValidKey := fromNative(entry.Key)
+			converted, isValid := fromNative(entry.Value)
+			if !isValidKey || !isValid || m.SetIndex(key, converted) != nil {
+				return nil, false
+			}
+		}
+		return m, true
+	default:
+		return nil, false
+	}
+}
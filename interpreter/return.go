@@ -1,9 +1,9 @@
 package interpreter
 
 type Return struct {
-    Value interface {}
+	Value interface{}
 }
 
 func (r *Return) Error() string {
-    return "Return statement."
+	return "Return statement."
 }
@@ -0,0 +1,14 @@
+package interpreter
+
+import "github.com/jordanwebster/golox/loxerror"
+
+// Throw unwinds to the nearest enclosing catch clause with the thrown value.
+// err is reported instead if nothing catches it.
+type Throw struct {
+	Value interface{}
+	err   *loxerror.RuntimeError
+}
+
+func (t *Throw) Error() string {
+	return t.err.Error()
+}
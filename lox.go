@@ -1,94 +1,175 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/jordanwebster/golox/ast"
-	"github.com/jordanwebster/golox/interpreter"
+	"github.com/jordanwebster/golox/golox"
 	"github.com/jordanwebster/golox/loxerror"
-	"github.com/jordanwebster/golox/loxio"
-	"github.com/jordanwebster/golox/parser"
-	"github.com/jordanwebster/golox/scanner"
-	"github.com/jordanwebster/golox/token"
 )
 
 //go:generate go run ./ast/cmd/gen.go
 //go:generate go fmt ./ast
 
-var globalInterpreter *interpreter.Interpreter = interpreter.NewInterpreter()
+const usage = `Usage: golox [--vm] [script]
+       golox test [--vm] [path ...]
+
+A script file named test is run by "golox test"; run its directory's tests
+with "golox test ." instead.`
+
+var useVM = flag.Bool("vm", false, "run scripts on the bytecode virtual machine")
 
 func main() {
-	switch numArgs := len(os.Args); numArgs {
+	flag.Parse()
+
+	if flag.Arg(0) == "test" && !(flag.NArg() == 1 && isFile("test")) {
+		os.Exit(runTests(flag.Args()[1:]))
+	}
+
+	lox := golox.New(golox.Options{
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		Bytecode:   *useVM,
+		FileSystem: true,
+	})
+
+	switch numArgs := flag.NArg(); numArgs {
+	case 0:
+		lox.RunPrompt(os.Stdin)
 	case 1:
-		runPrompt()
-	case 2:
-		runFile(os.Args[1])
+		runFile(lox, flag.Arg(0))
 	default:
-		fmt.Println("Usage: golox [script]")
+		fmt.Fprintln(os.Stderr, usage)
 		os.Exit(64)
 	}
 }
 
-func runPrompt() {
-	read_writer := loxio.NewChannelReadWriter()
-	go readLines(read_writer)
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
 
-	tokens := make(chan token.Token)
-	scanner := scanner.NewScanner(read_writer, tokens)
-	go scanner.ScanTokens()
+func runFile(lox *golox.Runtime, path string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	statements := make(chan ast.Stmt)
-	parser := parser.NewParser(tokens, statements)
-	go parser.Parse()
+	err = lox.EvalFile(context.Background(), path, string(source))
+	if err == nil {
+		return
+	}
 
-	for stmt := range statements {
-		globalInterpreter.Interpret([]ast.Stmt{stmt})
+	diagnostics := lox.Diagnostics()
+	if !diagnostics.HasErrors() {
+		log.Fatal(err)
 	}
 
+	renderer := loxerror.NewRenderer(path, string(source), useColor())
+	renderer.RenderAll(os.Stderr, diagnostics)
+	if diagnostics.HasRuntimeErrors() {
+		os.Exit(70)
+	}
+	os.Exit(65)
 }
 
-func readLines(writer *loxio.ChannelReadWriter) {
-	line_scanner := bufio.NewScanner(os.Stdin)
-	fmt.Print("> ")
-	for line_scanner.Scan() {
-		line := line_scanner.Bytes()
-		writer.Write(line)
-		fmt.Print("> ")
+// runTests runs the tests in every *_test.lox file found under the paths in
+// args, or the working directory if none are given, and returns the exit
+// code. Flags may come before or after the paths.
+func runTests(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	bytecode := flags.Bool("vm", *useVM, "run tests on the bytecode virtual machine")
+
+	var paths []string
+	flags.Parse(args)
+	for flags.NArg() > 0 {
+		paths = append(paths, flags.Arg(0))
+		flags.Parse(flags.Args()[1:])
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
 	}
 
-	writer.Close()
-}
-
-func runFile(path string) {
-	source, err := os.ReadFile(path)
-	if err != nil {
-		log.Fatal(err)
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named explicitly are run whatever they are called.
+			if !entry.IsDir() && (file == path || strings.HasSuffix(file, "_test.lox")) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
 	}
 
-	tokens := make(chan token.Token)
-	scanner := scanner.NewScanner(strings.NewReader(string(source)), tokens)
-	go scanner.ScanTokens()
+	passed, failed := 0, 0
+	for _, file := range files {
+		for _, result := range golox.RunTests(context.Background(), file, golox.Options{Bytecode: *bytecode, FileSystem: true}) {
+			if result.Err == nil {
+				passed++
+				fmt.Printf("PASS %s %s\n", file, result.Name)
+				continue
+			}
+
+			failed++
+			if result.Name == "" {
+				fmt.Printf("FAIL %s\n", file)
+			} else {
+				fmt.Printf("FAIL %s %s\n", file, result.Name)
+			}
+			// Output is only shown for failures, where it may explain them.
+			fmt.Print(result.Output)
+			reportTestFailure(file, result)
+		}
+	}
 
-	statements := make(chan ast.Stmt)
-	parser := parser.NewParser(tokens, statements)
-	go parser.Parse()
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
 
-	stmts := make([]ast.Stmt, 0, 64)
-	for stmt := range statements {
-		stmts = append(stmts, stmt)
+func reportTestFailure(file string, result golox.TestResult) {
+	if !result.Diagnostics.HasErrors() {
+		fmt.Fprintln(os.Stderr, result.Err)
+		return
 	}
 
-	if loxerror.HadError() {
-		os.Exit(65)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, result.Err)
+		return
 	}
 
-	globalInterpreter.Interpret(stmts)
+	renderer := loxerror.NewRenderer(file, string(source), useColor())
+	renderer.RenderAll(os.Stderr, result.Diagnostics)
+}
 
-	if loxerror.HadRuntimeError() {
-		os.Exit(70)
+// useColor reports whether errors should be highlighted, which is only
+// when stderr, where they are written, is a terminal and the user hasn't
+// opted out with NO_COLOR.
+func useColor() bool {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return false
 	}
+
+	info, err := os.Stderr.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
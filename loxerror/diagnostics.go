@@ -0,0 +1,249 @@
+package loxerror
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jordanwebster/golox/token"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+type Kind int
+
+const (
+	// KindSyntax covers errors found while scanning or compiling bytecode.
+	KindSyntax Kind = iota
+	// KindParse covers errors from the parser and the resolver.
+	KindParse
+	KindRuntime
+)
+
+// Code is the identifier printed alongside errors of this kind, so that
+// users can search for them.
+func (kind Kind) Code() string {
+	switch kind {
+	case KindSyntax:
+		return "E0001"
+	case KindParse:
+		return "E0002"
+	default:
+		return "E0003"
+	}
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Kind     Kind
+	Code     string
+	// File is the imported module the error is in, or empty for the main
+	// script.
+	File string
+	Line int
+	// Span is the source range the error points at. It is the zero Span if
+	// only the line is known.
+	Span    token.Span
+	Message string
+	Help    []string
+	// Trace is the call stack of a runtime error, innermost frame first.
+	Trace []Frame
+	// Err is the error that was reported, for callers that need more detail
+	// than the fields above.
+	Err error
+}
+
+func (diagnostic Diagnostic) String() string {
+	lines := []string{diagnostic.Err.Error()}
+	if diagnostic.File != "" {
+		lines[0] = diagnostic.File + ": " + lines[0]
+	}
+	lines = append(lines, traceLines(diagnostic.Trace, func(file string, line int) string {
+		if file != "" {
+			return fmt.Sprintf("%s:%d", file, line)
+		}
+		return fmt.Sprintf("line %d", line)
+	})...)
+
+	return strings.Join(lines, "\n")
+}
+
+// maxTraceFrames limits how much of a deep stack, such as one that
+// overflowed, is shown. The frames in the middle are elided.
+const maxTraceFrames = 20
+
+// traceLines formats a stack trace as "  at fib (script.lox:4)" lines. A
+// trace holding only the top-level script adds nothing to the error's own
+// location so it is left out.
+func traceLines(trace []Frame, location func(file string, line int) string) []string {
+	if len(trace) < 2 {
+		return nil
+	}
+
+	var lines []string
+	for i, frame := range trace {
+		if len(trace) > maxTraceFrames && i >= maxTraceFrames/2 && i < len(trace)-maxTraceFrames/2 {
+			if i == maxTraceFrames/2 {
+				lines = append(lines, fmt.Sprintf("  ... %d more frames", len(trace)-maxTraceFrames))
+			}
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  at %s (%s)", frame.Function, location(frame.File, frame.Line)))
+	}
+
+	return lines
+}
+
+// Diagnostics collects every error reported during a run. The scanner and
+// parser report from their own goroutines so it is safe for concurrent use.
+type Diagnostics struct {
+	mutex    sync.Mutex
+	items    []Diagnostic
+	onReport func(Diagnostic)
+}
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{}
+}
+
+// OnReport registers a function to be called with each diagnostic as it is
+// reported, for callers such as the REPL that show errors immediately.
+func (diagnostics *Diagnostics) OnReport(onReport func(Diagnostic)) {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	diagnostics.onReport = onReport
+}
+
+// Report records an error. SyntaxError, ParseError and RuntimeError are
+// classified by kind; any other error is recorded as a runtime error
+// without a line.
+func (diagnostics *Diagnostics) Report(err error) {
+	diagnostic := Diagnostic{
+		Severity: SeverityError,
+		Kind:     KindRuntime,
+		Message:  err.Error(),
+		Err:      err,
+	}
+
+	switch e := err.(type) {
+	case *SyntaxError:
+		diagnostic.Kind = KindSyntax
+		diagnostic.Line = e.Line()
+		diagnostic.Span = e.Span()
+		diagnostic.Message = e.Message()
+		diagnostic.Help = e.Help()
+	case *ParseError:
+		diagnostic.Kind = KindParse
+		diagnostic.Line = e.Token().Line
+		diagnostic.Span = e.Token().Span
+		diagnostic.Message = e.Message()
+		diagnostic.Help = e.Help()
+	case *RuntimeError:
+		diagnostic.Line = e.Token().Line
+		diagnostic.Span = e.Token().Span
+		diagnostic.Message = e.Message()
+		diagnostic.Help = e.Help()
+		diagnostic.Trace = e.Trace()
+		if len(diagnostic.Trace) > 0 {
+			diagnostic.File = diagnostic.Trace[0].File
+		}
+	}
+	diagnostic.Code = diagnostic.Kind.Code()
+
+	diagnostics.add(diagnostic)
+}
+
+// Merge records the diagnostics of other, which were reported against the
+// imported module file.
+func (diagnostics *Diagnostics) Merge(other *Diagnostics, file string) {
+	for _, diagnostic := range other.All() {
+		diagnostic.File = file
+		diagnostics.add(diagnostic)
+	}
+}
+
+func (diagnostics *Diagnostics) add(diagnostic Diagnostic) {
+	diagnostics.mutex.Lock()
+	diagnostics.items = append(diagnostics.items, diagnostic)
+	onReport := diagnostics.onReport
+	diagnostics.mutex.Unlock()
+
+	if onReport != nil {
+		onReport(diagnostic)
+	}
+}
+
+// All returns a copy of the diagnostics reported so far, in order.
+func (diagnostics *Diagnostics) All() []Diagnostic {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	return append([]Diagnostic(nil), diagnostics.items...)
+}
+
+func (diagnostics *Diagnostics) Len() int {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	return len(diagnostics.items)
+}
+
+// HasErrors reports whether any static or runtime error was reported.
+func (diagnostics *Diagnostics) HasErrors() bool {
+	return diagnostics.count(func(d Diagnostic) bool { return d.Severity == SeverityError }) > 0
+}
+
+func (diagnostics *Diagnostics) HasRuntimeErrors() bool {
+	return diagnostics.count(func(d Diagnostic) bool { return d.Kind == KindRuntime }) > 0
+}
+
+// Errors returns the reported errors themselves, for callers that want to
+// wrap them into a single Go error.
+func (diagnostics *Diagnostics) Errors() []error {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	errs := make([]error, 0, len(diagnostics.items))
+	for _, diagnostic := range diagnostics.items {
+		errs = append(errs, diagnostic.Err)
+	}
+	return errs
+}
+
+func (diagnostics *Diagnostics) Clear() {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	diagnostics.items = nil
+}
+
+// Render writes every diagnostic to w, one per line, in the order they were
+// reported. Use a Renderer to show them alongside the source.
+func (diagnostics *Diagnostics) Render(w io.Writer) {
+	for _, diagnostic := range diagnostics.All() {
+		fmt.Fprintln(w, diagnostic)
+	}
+}
+
+func (diagnostics *Diagnostics) count(predicate func(Diagnostic) bool) int {
+	diagnostics.mutex.Lock()
+	defer diagnostics.mutex.Unlock()
+	n := 0
+	for _, diagnostic := range diagnostics.items {
+		if predicate(diagnostic) {
+			n++
+		}
+	}
+	return n
+}
@@ -6,12 +6,32 @@ import (
 	"github.com/jordanwebster/golox/token"
 )
 
-var hadError = false
-var hadRuntimeError = false
+// Frame is one active function call in the stack trace of a RuntimeError.
+type Frame struct {
+	// Function is the name of the function, or "<script>" for top-level code
+	// and "<module>" for the top-level code of an imported module.
+	Function string
+	// File is the module the function was declared in, or empty for the
+	// main script.
+	File string
+	// Line is the line being executed in that function: where the error
+	// occurred for the innermost frame and the call site for the others.
+	Line int
+}
+
+func (frame Frame) String() string {
+	if frame.File != "" {
+		return fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+	}
+
+	return fmt.Sprintf("%s (line %d)", frame.Function, frame.Line)
+}
 
 type RuntimeError struct {
 	message string
 	token   token.Token
+	help    []string
+	trace   []Frame
 }
 
 func (e *RuntimeError) Error() string {
@@ -25,18 +45,40 @@ func NewRuntimeError(t token.Token, message string) *RuntimeError {
 	}
 }
 
-func ReportRuntimeError(e *RuntimeError) {
-	fmt.Println(e.Error())
-	hadRuntimeError = true
+func (e *RuntimeError) Message() string {
+	return e.message
+}
+
+func (e *RuntimeError) Token() token.Token {
+	return e.token
+}
+
+// WithHelp attaches notes suggesting how to fix the error. They are shown by
+// the Renderer but are not part of Error().
+func (e *RuntimeError) WithHelp(notes ...string) *RuntimeError {
+	e.help = append(e.help, notes...)
+	return e
+}
+
+func (e *RuntimeError) Help() []string {
+	return e.help
+}
+
+// WithTrace records the call stack at the point the error occurred,
+// innermost frame first.
+func (e *RuntimeError) WithTrace(trace []Frame) *RuntimeError {
+	e.trace = trace
+	return e
 }
 
-func HadRuntimeError() bool {
-	return hadRuntimeError
+func (e *RuntimeError) Trace() []Frame {
+	return e.trace
 }
 
 type ParseError struct {
 	message string
 	token   token.Token
+	help    []string
 }
 
 func (e *ParseError) Error() string {
@@ -57,31 +99,68 @@ func NewParseError(t token.Token, message string) *ParseError {
 	}
 }
 
+func (e *ParseError) Message() string {
+	return e.message
+}
+
+func (e *ParseError) Token() token.Token {
+	return e.token
+}
+
+func (e *ParseError) WithHelp(notes ...string) *ParseError {
+	e.help = append(e.help, notes...)
+	return e
+}
+
+func (e *ParseError) Help() []string {
+	return e.help
+}
+
 type SyntaxError struct {
-    message string
-    line int
+	message string
+	line    int
+	span    token.Span
+	help    []string
 }
 
 func (e *SyntaxError) Error() string {
-    return fmt.Sprintf("[line %d] Error: %s", e.line, e.message)
+	return fmt.Sprintf("[line %d] Error: %s", e.line, e.message)
 }
 
 func NewSyntaxError(line int, message string) *SyntaxError {
-    return &SyntaxError{
-        message: message,
-        line: line,
-    }
+	return &SyntaxError{
+		message: message,
+		line:    line,
+	}
+}
+
+// NewSyntaxErrorAt creates a SyntaxError covering a range of source. The
+// line reported is the one the span ends on.
+func NewSyntaxErrorAt(span token.Span, message string) *SyntaxError {
+	return &SyntaxError{
+		message: message,
+		line:    span.End.Line,
+		span:    span,
+	}
+}
+
+func (e *SyntaxError) Message() string {
+	return e.message
+}
+
+func (e *SyntaxError) Line() int {
+	return e.line
 }
 
-func ReportError(e error) {
-	fmt.Println(e.Error())
-	hadError = true
+func (e *SyntaxError) Span() token.Span {
+	return e.span
 }
 
-func ClearError() {
-	hadError = false
+func (e *SyntaxError) WithHelp(notes ...string) *SyntaxError {
+	e.help = append(e.help, notes...)
+	return e
 }
 
-func HadError() bool {
-	return hadError
+func (e *SyntaxError) Help() []string {
+	return e.help
 }
@@ -0,0 +1,206 @@
+package loxerror
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[1;31m"
+	colorYel   = "\x1b[1;33m"
+	colorBlue  = "\x1b[1;34m"
+	colorCyan  = "\x1b[1;36m"
+	colorBold  = "\x1b[1m"
+)
+
+// Renderer prints diagnostics alongside the source they refer to:
+//
+//	error[E0002]: Expect ';' after value.
+//	 --> script.lox:1:8
+//	  |
+//	1 | print 1
+//	  |        ^
+//	  = help: statements must end with ';'
+//
+// Diagnostics in imported modules are shown against their own file, which
+// is read from disk when first needed.
+type Renderer struct {
+	fileName string
+	lines    []string
+	modules  map[string][]string
+	color    bool
+}
+
+// NewRenderer creates a Renderer for diagnostics reported against source,
+// which was read from fileName. Colour escape codes are only written if
+// color is set.
+func NewRenderer(fileName string, source string, color bool) *Renderer {
+	if fileName == "" {
+		fileName = "<input>"
+	}
+
+	return &Renderer{
+		fileName: fileName,
+		lines:    strings.Split(source, "\n"),
+		modules:  make(map[string][]string),
+		color:    color,
+	}
+}
+
+// RenderAll renders every diagnostic in source order, separated by blank
+// lines, with those in the main script first. The scanner and parser report
+// concurrently so the order they were reported in can vary from run to run.
+func (renderer *Renderer) RenderAll(w io.Writer, diagnostics *Diagnostics) {
+	all := diagnostics.All()
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].File != all[j].File {
+			return all[i].File < all[j].File
+		}
+		iLine, iColumn := location(all[i])
+		jLine, jColumn := location(all[j])
+		return iLine < jLine || (iLine == jLine && iColumn < jColumn)
+	})
+
+	for i, diagnostic := range all {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		renderer.Render(w, diagnostic)
+	}
+}
+
+func (renderer *Renderer) Render(w io.Writer, diagnostic Diagnostic) {
+	severityColor := colorRed
+	if diagnostic.Severity == SeverityWarning {
+		severityColor = colorYel
+	}
+
+	fmt.Fprintf(w, "%s[%s]%s\n",
+		renderer.paint(severityColor, diagnostic.Severity.String()),
+		diagnostic.Code,
+		renderer.paint(colorBold, ": "+diagnostic.Message))
+
+	line, _ := location(diagnostic)
+
+	gutter := strings.Repeat(" ", len(strconv.Itoa(line)))
+	location := renderer.location(diagnostic.File, line)
+	if line > 0 && diagnostic.Span.IsValid() {
+		location += ":" + strconv.Itoa(diagnostic.Span.Start.Column)
+	}
+	fmt.Fprintf(w, "%s%s %s\n", gutter, renderer.paint(colorBlue, "-->"), location)
+
+	if text, ok := renderer.line(diagnostic.File, line); ok {
+		bar := renderer.paint(colorBlue, "|")
+		fmt.Fprintf(w, "%s %s\n", gutter, bar)
+		fmt.Fprintf(w, "%s %s %s\n", renderer.paint(colorBlue, strconv.Itoa(line)), bar, text)
+		if diagnostic.Span.IsValid() {
+			fmt.Fprintf(w, "%s %s %s\n", gutter, bar, renderer.underline(text, diagnostic))
+		}
+	}
+
+	for _, note := range diagnostic.Help {
+		fmt.Fprintf(w, "%s %s %s\n", gutter, renderer.paint(colorBlue, "="), renderer.paint(colorCyan, "help:")+" "+note)
+	}
+
+	for _, line := range traceLines(diagnostic.Trace, renderer.location) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (renderer *Renderer) location(file string, line int) string {
+	if file == "" {
+		file = renderer.fileName
+	}
+	if line < 1 {
+		return file
+	}
+
+	return file + ":" + strconv.Itoa(line)
+}
+
+// location is where the diagnostic points, with a column of zero if only the
+// line is known.
+func location(diagnostic Diagnostic) (int, int) {
+	if diagnostic.Span.IsValid() {
+		return diagnostic.Span.Start.Line, diagnostic.Span.Start.Column
+	}
+
+	return diagnostic.Line, 0
+}
+
+func (renderer *Renderer) line(file string, line int) (string, bool) {
+	lines := renderer.lines
+	if file != "" {
+		lines = renderer.moduleLines(file)
+	}
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// moduleLines returns the source of an imported module, or nothing if it
+// can no longer be read.
+func (renderer *Renderer) moduleLines(file string) []string {
+	if lines, isLoaded := renderer.modules[file]; isLoaded {
+		return lines
+	}
+
+	var lines []string
+	if source, err := os.ReadFile(file); err == nil {
+		lines = strings.Split(string(source), "\n")
+	}
+	renderer.modules[file] = lines
+	return lines
+}
+
+// underline builds the "^~~~" marker for the diagnostic's span. Tabs in the
+// source line are copied into the padding so the marker lines up however
+// the terminal expands them.
+func (renderer *Renderer) underline(text string, diagnostic Diagnostic) string {
+	span := diagnostic.Span
+
+	var padding strings.Builder
+	column := 1
+	for _, r := range text {
+		if column >= span.Start.Column {
+			break
+		}
+		if r == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+		column++
+	}
+	// A span at the end of the input starts past the last character.
+	for ; column < span.Start.Column; column++ {
+		padding.WriteRune(' ')
+	}
+
+	// Spans over several lines are underlined to the end of the first.
+	width := span.End.Column - span.Start.Column
+	if span.End.Line != span.Start.Line {
+		width = utf8.RuneCountInString(text) - span.Start.Column + 1
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	return padding.String() + renderer.paint(colorRed, "^"+strings.Repeat("~", width-1))
+}
+
+func (renderer *Renderer) paint(color string, text string) string {
+	if !renderer.color {
+		return text
+	}
+
+	return color + text + colorReset
+}
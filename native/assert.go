@@ -0,0 +1,114 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// AssertFunctions returns the assertions that test scripts can call. A
+// failed assertion is an error, which fails the test unless it is caught.
+func AssertFunctions() []*Function {
+	return mustWrap(map[string]interface{}{
+		"assert":      assert,
+		"assertEqual": assertEqual,
+	})
+}
+
+// assert fails unless value is truthy, with message if one is given.
+func assert(value interface{}, message ...string) error {
+	if len(message) > 1 {
+		return fmt.Errorf("Expected at most 2 arguments but got %d.", len(message)+1)
+	}
+	if value != nil && value != false {
+		return nil
+	}
+
+	if len(message) == 1 {
+		return errors.New(message[0])
+	}
+	return errors.New("Assertion failed.")
+}
+
+// assertEqual fails unless actual equals expected. Lists and maps are equal
+// if their contents are, unlike with ==.
+func assertEqual(actual interface{}, expected interface{}) error {
+	if !equal(actual, expected, make(map[pair]bool)) {
+		return fmt.Errorf("Expected %s but got %s.", describe(expected), describe(actual))
+	}
+
+	return nil
+}
+
+// pair is two containers being compared.
+type pair struct {
+	a interface{}
+	b interface{}
+}
+
+// equal compares containers by their contents. Pairs already being compared
+// further up are taken to be equal so that containers holding themselves
+// don't recurse forever; any difference is found elsewhere.
+func equal(a interface{}, b interface{}, seen map[pair]bool) bool {
+	switch a.(type) {
+	case List, Map:
+		if a == b || seen[pair{a, b}] {
+			return true
+		}
+		seen[pair{a, b}] = true
+		defer delete(seen, pair{a, b})
+	}
+
+	switch a := a.(type) {
+	case List:
+		b, isList := b.(List)
+		if !isList || a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.At(i), b.At(i), seen) {
+				return false
+			}
+		}
+		return true
+	case Map:
+		b, isMap := b.(Map)
+		if !isMap || len(a.Entries()) != len(b.Entries()) {
+			return false
+		}
+		for _, entry := range a.Entries() {
+			value, isPresent := lookup(b, entry.Key)
+			if !isPresent || !equal(entry.Value, value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func lookup(m Map, key interface{}) (interface{}, bool) {
+	for _, entry := range m.Entries() {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// describe formats a value for an assertion message. Strings are quoted so
+// that "1" can be told apart from 1.
+func describe(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case string:
+		return strconv.Quote(value)
+	}
+
+	return fmt.Sprint(value)
+}
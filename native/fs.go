@@ -0,0 +1,209 @@
+package native
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FileSystem returns the fs global, which reads and changes files. Runtimes
+// only define it when asked to, since it gives scripts the same access to
+// files as the program running them. Failures are returned as errors
+// carrying the operating system's reason so that scripts can catch them.
+func FileSystem() *Namespace {
+	return NewNamespace("fs", map[string]interface{}{
+		"readFile":   readFile,
+		"writeFile":  writeFile,
+		"appendFile": appendFile,
+		"readLines":  readLines,
+		"exists":     exists,
+		"listDir":    listDir,
+		"mkdir":      mkdir,
+		"remove":     remove,
+		"open":       open,
+	})
+}
+
+func readFile(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fsError("read", path, err)
+	}
+
+	return string(contents), nil
+}
+
+func writeFile(path string, contents string) error {
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		return fsError("write", path, err)
+	}
+
+	return nil
+}
+
+func appendFile(path string, contents string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fsError("open", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		return fsError("write", path, err)
+	}
+	return nil
+}
+
+// readLines splits a file into lines without their line endings.
+func readLines(path string) ([]string, error) {
+	contents, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+	if contents == "" {
+		lines = nil
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// listDir returns the names of the entries in a directory, sorted.
+func listDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fsError("list", path, err)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mkdir creates a directory along with any missing parents.
+func mkdir(path string) error {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return fsError("create", path, err)
+	}
+
+	return nil
+}
+
+// remove deletes a file or an empty directory.
+func remove(path string) error {
+	if err := os.Remove(path); err != nil {
+		return fsError("remove", path, err)
+	}
+
+	return nil
+}
+
+// open opens a file for reading ("r"), writing from scratch ("w") or
+// appending ("a").
+func open(path string, mode string) (*File, error) {
+	var flag int
+	switch mode {
+	case "r":
+		flag = os.O_RDONLY
+	case "w":
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case "a":
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	default:
+		return nil, fmt.Errorf("Unknown file mode '%s'; use \"r\", \"w\" or \"a\".", mode)
+	}
+
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, fsError("open", path, err)
+	}
+
+	return &File{path: path, file: file, reader: bufio.NewReader(file)}, nil
+}
+
+// File is a handle returned by open.
+type File struct {
+	path   string
+	file   *os.File
+	reader *bufio.Reader
+}
+
+func (file *File) String() string {
+	return fmt.Sprintf("<file %s>", file.path)
+}
+
+func (file *File) Method(name string) (*Function, bool) {
+	var fn interface{}
+	switch name {
+	case "readLine":
+		fn = file.readLine
+	case "write":
+		fn = file.write
+	case "close":
+		fn = file.close
+	default:
+		return nil, false
+	}
+
+	function, err := Wrap(name, fn)
+	if err != nil {
+		panic(err)
+	}
+	return function, true
+}
+
+// readLine returns the next line without its line ending, or nil at the end
+// of the file.
+func (file *File) readLine() (interface{}, error) {
+	line, err := file.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, nil
+	} else if err != nil && err != io.EOF {
+		return nil, fsError("read", file.path, err)
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (file *File) write(contents string) error {
+	if _, err := file.file.WriteString(contents); err != nil {
+		return fsError("write", file.path, err)
+	}
+
+	return nil
+}
+
+func (file *File) close() error {
+	if err := file.file.Close(); err != nil {
+		return fsError("close", file.path, err)
+	}
+
+	return nil
+}
+
+// fsError describes a failed operation on path using the operating system's
+// reason, without repeating the path and operation it already names.
+func fsError(action string, path string, err error) error {
+	var pathError *fs.PathError
+	if errors.As(err, &pathError) {
+		err = pathError.Err
+	}
+
+	return fmt.Errorf("Can't %s '%s': %s.", action, path, err)
+}
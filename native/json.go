@@ -0,0 +1,192 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON returns the json global, which converts between JSON text and Lox
+// values. Objects become maps that keep the order of their keys.
+func JSON() *Namespace {
+	return NewNamespace("json", map[string]interface{}{
+		"parse":     parseJSON,
+		"stringify": stringifyJSON,
+	})
+}
+
+// parseJSON checks the text with Unmarshal, which locates syntax errors
+// precisely, before decoding it token by token to keep the order of object
+// keys.
+func parseJSON(text string) (interface{}, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, jsonError(err)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	value, err := decodeJSON(decoder)
+	if err != nil {
+		return nil, jsonError(err)
+	}
+	return value, nil
+}
+
+func decodeJSON(decoder *json.Decoder) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		elements := []interface{}{}
+		for decoder.More() {
+			element, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		_, err := decoder.Token()
+		return elements, err
+	case json.Delim('{'):
+		object := NewOrderedMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.Add(key, value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	}
+
+	// Numbers, strings, booleans and null already have the representation
+	// natives use.
+	return tok, nil
+}
+
+// jsonError reports a syntax error at the offset of the byte it was found
+// at, counting from zero.
+func jsonError(err error) error {
+	var syntaxError *json.SyntaxError
+	if !errors.As(err, &syntaxError) {
+		return fmt.Errorf("Invalid JSON: %s.", err)
+	}
+
+	offset := syntaxError.Offset
+	if strings.HasPrefix(syntaxError.Error(), "invalid character") {
+		// The offset counts the invalid character itself.
+		offset--
+	}
+	return fmt.Errorf("Invalid JSON at offset %d: %s.", offset, syntaxError)
+}
+
+// stringifyJSON encodes a value as compact JSON, or indented by the given
+// number of spaces per level.
+func stringifyJSON(value interface{}, indent ...int) (string, error) {
+	if len(indent) > 1 {
+		return "", fmt.Errorf("Expected at most 2 arguments but got %d.", len(indent)+1)
+	}
+
+	var buffer bytes.Buffer
+	if err := encodeJSON(&buffer, value, make(map[interface{}]bool)); err != nil {
+		return "", err
+	}
+
+	if len(indent) == 0 {
+		return buffer.String(), nil
+	}
+	if indent[0] < 0 {
+		return "", errors.New("JSON indent must not be negative.")
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buffer.Bytes(), "", strings.Repeat(" ", indent[0])); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
+// encodeJSON writes value to buffer. Containers being encoded are tracked in
+// seen so that one containing itself is an error rather than endless.
+func encodeJSON(buffer *bytes.Buffer, value interface{}, seen map[interface{}]bool) error {
+	switch value := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(value))
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("Can't convert %s to JSON.", strconv.FormatFloat(value, 'f', -1, 64))
+		}
+		buffer.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	case string:
+		encodeJSONString(buffer, value)
+	case List:
+		if seen[value] {
+			return errors.New("Can't convert a list that contains itself to JSON.")
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		buffer.WriteByte('[')
+		for i := 0; i < value.Len(); i++ {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJSON(buffer, value.At(i), seen); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+	case Map:
+		if seen[value] {
+			return errors.New("Can't convert a map that contains itself to JSON.")
+		}
+		seen[value] = true
+		defer delete(seen, value)
+
+		buffer.WriteByte('{')
+		for i, entry := range value.Entries() {
+			key, isString := entry.Key.(string)
+			if !isString {
+				return fmt.Errorf("JSON object keys must be strings but got %s.", TypeName(entry.Key))
+			}
+
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			encodeJSONString(buffer, key)
+			buffer.WriteByte(':')
+			if err := encodeJSON(buffer, entry.Value, seen); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+	default:
+		return fmt.Errorf("Can't convert %v to JSON.", value)
+	}
+
+	return nil
+}
+
+func encodeJSONString(buffer *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string can't fail. The encoder ends each value with a
+	// newline, which is dropped.
+	encoder.Encode(s)
+	buffer.Truncate(buffer.Len() - 1)
+}
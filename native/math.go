@@ -0,0 +1,83 @@
+package native
+
+import (
+	"errors"
+	"math"
+)
+
+// MathConstants are the numeric globals defined alongside MathFunctions.
+var MathConstants = map[string]float64{
+	"PI": math.Pi,
+	"E":  math.E,
+}
+
+// MathFunctions returns the math library that both backends define as
+// globals, wrapped so that they check the types of their arguments.
+func MathFunctions() []*Function {
+	return mustWrap(map[string]interface{}{
+		"sqrt":  math.Sqrt,
+		"pow":   math.Pow,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"abs":   math.Abs,
+		"min":   minNumber,
+		"max":   maxNumber,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"atan2": math.Atan2,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+		"div":   div,
+		"mod":   mod,
+	})
+}
+
+func minNumber(first float64, rest ...float64) float64 {
+	for _, number := range rest {
+		first = math.Min(first, number)
+	}
+
+	return first
+}
+
+func maxNumber(first float64, rest ...float64) float64 {
+	for _, number := range rest {
+		first = math.Max(first, number)
+	}
+
+	return first
+}
+
+// div divides integers, rounding towards negative infinity so that it
+// agrees with mod.
+func div(a int, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("Division by zero.")
+	}
+
+	quotient := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		quotient--
+	}
+	return quotient, nil
+}
+
+// mod is the remainder of div, which has the sign of the divisor.
+func mod(a int, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("Division by zero.")
+	}
+
+	remainder := a % b
+	if remainder != 0 && (remainder < 0) != (b < 0) {
+		remainder += b
+	}
+	return remainder, nil
+}
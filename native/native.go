@@ -0,0 +1,318 @@
+package native
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	listType  = reflect.TypeOf((*List)(nil)).Elem()
+	mapType   = reflect.TypeOf((*Map)(nil)).Elem()
+)
+
+// Function adapts an arbitrary Go function so that it can be called with
+// Lox values. Lox numbers are converted to any Go numeric parameter type,
+// strings and booleans map directly, and interface{} parameters receive the
+// value untouched. Any other parameter type accepts values assignable to it.
+// Numbers that don't fit an integer parameter are rejected, as are functions
+// with slice, map or other parameters no Lox value can be converted to.
+//
+// The Go function may return nothing, a single value, an error, or a value
+// followed by an error. Slices it returns become []interface{}, which the
+// backends turn into lists.
+type Function struct {
+	name     string
+	fn       reflect.Value
+	fnType   reflect.Type
+	hasValue bool
+	hasError bool
+	// receiver holds the value a method is bound to, which is passed ahead
+	// of the arguments.
+	receiver []interface{}
+}
+
+func Wrap(name string, fn interface{}) (*Function, error) {
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func {
+		return nil, fmt.Errorf("native '%s' must be a function, not %T", name, fn)
+	}
+
+	function := &Function{
+		name:   name,
+		fn:     fnValue,
+		fnType: fnValue.Type(),
+	}
+
+	for i := 0; i < function.fnType.NumIn(); i++ {
+		paramType := function.fnType.In(i)
+		if function.fnType.IsVariadic() && i == function.fnType.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		// No Lox value converts to these, so the function could never be
+		// called.
+		switch paramType.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Chan, reflect.Func:
+			return nil, fmt.Errorf("native '%s' can't take a parameter of type %s", name, paramType)
+		}
+	}
+
+	switch numOut := function.fnType.NumOut(); {
+	case numOut == 0:
+	case numOut == 1 && function.fnType.Out(0) == errorType:
+		function.hasError = true
+	case numOut == 1:
+		function.hasValue = true
+	case numOut == 2 && function.fnType.Out(1) == errorType:
+		function.hasValue = true
+		function.hasError = true
+	default:
+		return nil, fmt.Errorf("native '%s' must return at most a value and an error", name)
+	}
+
+	return function, nil
+}
+
+func (function *Function) Name() string {
+	return function.name
+}
+
+// Arity is the number of arguments the function requires. Variadic
+// functions accept any number of arguments beyond this.
+func (function *Function) Arity() int {
+	arity := function.fnType.NumIn() - len(function.receiver)
+	if function.fnType.IsVariadic() {
+		return arity - 1
+	}
+
+	return arity
+}
+
+func (function *Function) IsVariadic() bool {
+	return function.fnType.IsVariadic()
+}
+
+// Bind returns the function as a method of receiver, which is passed as its
+// first argument.
+func (function *Function) Bind(receiver interface{}) *Function {
+	bound := *function
+	bound.receiver = []interface{}{receiver}
+	return &bound
+}
+
+// Call converts the arguments, calls the Go function and converts its
+// result back to a Lox value. The caller is responsible for checking the
+// number of arguments against Arity.
+func (function *Function) Call(arguments []interface{}) (interface{}, error) {
+	if function.receiver != nil {
+		arguments = append(append([]interface{}(nil), function.receiver...), arguments...)
+	}
+
+	in := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		var paramType reflect.Type
+		if function.fnType.IsVariadic() && i >= function.fnType.NumIn()-1 {
+			paramType = function.fnType.In(function.fnType.NumIn() - 1).Elem()
+		} else {
+			paramType = function.fnType.In(i)
+		}
+
+		// Arguments are numbered as the script passed them.
+		converted, err := function.toGo(i-len(function.receiver), argument, paramType)
+		if err != nil {
+			return nil, err
+		}
+		in[i] = converted
+	}
+
+	out, err := function.call(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if function.hasError {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return nil, err
+		}
+	}
+
+	if !function.hasValue {
+		return nil, nil
+	}
+
+	return toLox(out[0]), nil
+}
+
+// call calls the Go function, turning a panic into an error so that a
+// faulty native fails the script rather than the program running it.
+func (function *Function) call(in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Native function '%s' panicked: %v.", function.name, recovered)
+		}
+	}()
+
+	return function.fn.Call(in), nil
+}
+
+func (function *Function) toGo(index int, argument interface{}, paramType reflect.Type) (reflect.Value, error) {
+	switch paramType.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if number, isNumber := argument.(float64); isNumber {
+			return reflect.ValueOf(number).Convert(paramType), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if number, isNumber := argument.(float64); isNumber {
+			if number != math.Trunc(number) {
+				return reflect.Value{}, fmt.Errorf("Argument %d to '%s' must be an integer.", index+1, function.name)
+			}
+			if !fitsInt(number, paramType) {
+				return reflect.Value{}, fmt.Errorf("Argument %d to '%s' is out of range.", index+1, function.name)
+			}
+			return reflect.ValueOf(number).Convert(paramType), nil
+		}
+	case reflect.String:
+		if str, isString := argument.(string); isString {
+			return reflect.ValueOf(str).Convert(paramType), nil
+		}
+	case reflect.Bool:
+		if b, isBool := argument.(bool); isBool {
+			return reflect.ValueOf(b).Convert(paramType), nil
+		}
+	default:
+		if argument == nil {
+			// Parameters such as a List need a value with their methods, so
+			// only interface{} accepts nil.
+			switch paramType.Kind() {
+			case reflect.Interface:
+				if paramType.NumMethod() == 0 {
+					return reflect.Zero(paramType), nil
+				}
+			case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func:
+				return reflect.Zero(paramType), nil
+			}
+		} else if reflect.TypeOf(argument).AssignableTo(paramType) {
+			return reflect.ValueOf(argument), nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("Argument %d to '%s' must be %s but got %s.", index+1, function.name, describeType(paramType), TypeName(argument))
+}
+
+// fitsInt reports whether a whole number can be converted to the integer
+// type t without overflowing. The bounds are checked as floats first because
+// converting an out of range float to an integer is undefined.
+func fitsInt(number float64, t reflect.Type) bool {
+	zero := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return number >= 0 && number < math.MaxUint64 && !zero.OverflowUint(uint64(number))
+	default:
+		return number >= math.MinInt64 && number < math.MaxInt64 && !zero.OverflowInt(int64(number))
+	}
+}
+
+func toLox(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return toLox(value.Elem())
+	case reflect.Slice:
+		if value.IsNil() {
+			return []interface{}{}
+		}
+		elements := make([]interface{}, value.Len())
+		for i := range elements {
+			elements[i] = toLox(value.Index(i))
+		}
+		return elements
+	case reflect.Pointer, reflect.Map, reflect.Func:
+		if value.IsNil() {
+			return nil
+		}
+	}
+
+	return value.Interface()
+}
+
+func describeType(t reflect.Type) string {
+	switch t {
+	case listType:
+		return "a list"
+	case mapType:
+		return "a map"
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a " + t.String()
+	}
+}
+
+// TypeName describes the type of a Lox value for use in error messages.
+func TypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case List:
+		return "list"
+	case Map:
+		return "map"
+	default:
+		return "object"
+	}
+}
+
+// mustWrap wraps the built-in functions a library defines, in name order.
+func mustWrap(functions map[string]interface{}) []*Function {
+	return mustWrapIn("", functions)
+}
+
+// mustWrapIn is like mustWrap for functions whose names are qualified by
+// the namespace they belong to.
+func mustWrapIn(namespace string, functions map[string]interface{}) []*Function {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	wrapped := make([]*Function, len(names))
+	for i, name := range names {
+		var err error
+		if wrapped[i], err = Wrap(namespace+name, functions[name]); err != nil {
+			panic(err)
+		}
+	}
+
+	return wrapped
+}
@@ -0,0 +1,81 @@
+package native
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Object is a Go value whose methods scripts can call, such as an open
+// file.
+type Object interface {
+	String() string
+	Method(name string) (*Function, bool)
+}
+
+// List is implemented by the backends' lists so that natives can read them.
+// Natives return new lists as slices instead.
+type List interface {
+	Len() int
+	At(i int) interface{}
+}
+
+// Map is implemented by the backends' maps so that natives can read them.
+// Natives return new maps as an *OrderedMap.
+type Map interface {
+	// Entries lists the map's entries in the order their keys were added.
+	Entries() []Entry
+}
+
+type Entry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// OrderedMap is a map built by a native function, which the backends turn
+// into one of their own.
+type OrderedMap struct {
+	entries []Entry
+}
+
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{}
+}
+
+func (m *OrderedMap) Entries() []Entry {
+	return m.entries
+}
+
+// Add appends an entry. A key added again replaces the earlier value once
+// the map is converted.
+func (m *OrderedMap) Add(key interface{}, value interface{}) {
+	m.entries = append(m.entries, Entry{Key: key, Value: value})
+}
+
+// Namespace is a global holding related natives, which scripts call as
+// properties of it, such as json.parse.
+type Namespace struct {
+	name    string
+	members map[string]*Function
+}
+
+func NewNamespace(name string, functions map[string]interface{}) *Namespace {
+	namespace := &Namespace{name: name, members: make(map[string]*Function)}
+	for _, function := range mustWrapIn(name+".", functions) {
+		namespace.members[strings.TrimPrefix(function.Name(), name+".")] = function
+	}
+
+	return namespace
+}
+
+func (namespace *Namespace) Name() string {
+	return namespace.name
+}
+
+func (namespace *Namespace) Method(name string) (*Function, bool) {
+	function, isPresent := namespace.members[name]
+	return function, isPresent
+}
+
+func (namespace *Namespace) String() string {
+	return fmt.Sprintf("<native %s>", namespace.name)
+}
@@ -0,0 +1,153 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStringLength is the longest string, in bytes, that a string method
+// will build.
+const maxStringLength = 1 << 30
+
+// stringMethods take the string they are called on as their first argument.
+// Positions count characters rather than bytes so that they work with any
+// UTF-8 text.
+var stringMethods = byName(mustWrap(map[string]interface{}{
+	"chars":      chars,
+	"endsWith":   strings.HasSuffix,
+	"indexOf":    indexOf,
+	"join":       join,
+	"len":        utf8.RuneCountInString,
+	"lower":      strings.ToLower,
+	"repeat":     repeat,
+	"replace":    replace,
+	"split":      split,
+	"startsWith": strings.HasPrefix,
+	"substr":     substr,
+	"trim":       strings.TrimSpace,
+	"upper":      strings.ToUpper,
+}))
+
+// StringMethod looks up a built-in method on a string, bound to that
+// string.
+func StringMethod(s string, name string) (*Function, bool) {
+	method, isPresent := stringMethods[name]
+	if !isPresent {
+		return nil, false
+	}
+
+	return method.Bind(s), true
+}
+
+func chars(s string) []string {
+	return strings.Split(s, "")
+}
+
+func indexOf(s string, substring string) int {
+	i := strings.Index(s, substring)
+	if i < 0 {
+		return -1
+	}
+
+	return utf8.RuneCountInString(s[:i])
+}
+
+func join(separator string, list List) string {
+	parts := make([]string, list.Len())
+	for i := range parts {
+		parts[i] = stringify(list.At(i))
+	}
+
+	return strings.Join(parts, separator)
+}
+
+func repeat(s string, count int) (string, error) {
+	if count < 0 {
+		return "", errors.New("Argument to 'repeat' must be a non-negative integer.")
+	}
+	if len(s) > 0 && count > maxStringLength/len(s) {
+		return "", errors.New("Result of 'repeat' would be too long.")
+	}
+
+	return strings.Repeat(s, count), nil
+}
+
+func replace(s string, old string, replacement string) string {
+	return strings.ReplaceAll(s, old, replacement)
+}
+
+func split(s string, separator string) []string {
+	return strings.Split(s, separator)
+}
+
+// substr returns the characters from start up to but not including end.
+// Either bound may be nil to mean the start or end of the string, negative
+// bounds count back from the end and bounds outside the string are clamped
+// to it.
+func substr(s string, start interface{}, end interface{}) (string, error) {
+	runes := []rune(s)
+	from, err := substringBound(1, start, 0, len(runes))
+	if err != nil {
+		return "", err
+	}
+
+	to, err := substringBound(2, end, len(runes), len(runes))
+	if err != nil {
+		return "", err
+	}
+
+	if to < from {
+		to = from
+	}
+	return string(runes[from:to]), nil
+}
+
+func substringBound(index int, value interface{}, fallback int, length int) (int, error) {
+	if value == nil {
+		return fallback, nil
+	}
+
+	number, isNumber := value.(float64)
+	if !isNumber || number != math.Trunc(number) {
+		return 0, fmt.Errorf("Argument %d to 'substr' must be an integer or nil.", index)
+	}
+
+	// The bound is clamped before it is converted, as a number too large for
+	// an int would otherwise wrap around.
+	if number < 0 {
+		number += float64(length)
+	}
+
+	if number < 0 {
+		return 0, nil
+	} else if number > float64(length) {
+		return length, nil
+	}
+
+	return int(number), nil
+}
+
+// stringify formats a value the way print shows it.
+func stringify(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func byName(functions []*Function) map[string]*Function {
+	named := make(map[string]*Function, len(functions))
+	for _, function := range functions {
+		named[function.Name()] = function
+	}
+
+	return named
+}
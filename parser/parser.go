@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jordanwebster/golox/ast"
 	"github.com/jordanwebster/golox/loxerror"
@@ -9,16 +10,18 @@ import (
 )
 
 type Parser struct {
-	tokens     chan token.Token
-	statements chan ast.Stmt
-	next       *token.Token
-	prev       *token.Token
+	tokens      chan token.Token
+	statements  chan ast.Stmt
+	next        *token.Token
+	prev        *token.Token
+	diagnostics *loxerror.Diagnostics
 }
 
-func NewParser(tokens chan token.Token, statements chan ast.Stmt) *Parser {
+func NewParser(tokens chan token.Token, statements chan ast.Stmt, diagnostics *loxerror.Diagnostics) *Parser {
 	return &Parser{
-		tokens:     tokens,
-		statements: statements,
+		tokens:      tokens,
+		statements:  statements,
+		diagnostics: diagnostics,
 	}
 }
 
@@ -44,8 +47,14 @@ func (parser *Parser) statement() (ast.Stmt, error) {
 		return parser.ifStatement()
 	} else if parser.match(token.PRINT) {
 		return parser.printStatement()
-    } else if parser.match(token.RETURN) {
-        return parser.returnStatement()
+	} else if parser.match(token.RETURN) {
+		return parser.returnStatement()
+	} else if parser.match(token.BREAK, token.CONTINUE) {
+		return parser.loopControlStatement()
+	} else if parser.match(token.THROW) {
+		return parser.throwStatement()
+	} else if parser.match(token.TRY) {
+		return parser.tryStatement()
 	} else if parser.match(token.WHILE) {
 		return parser.whileStatement()
 	} else if parser.match(token.LEFT_BRACE) {
@@ -58,10 +67,14 @@ func (parser *Parser) statement() (ast.Stmt, error) {
 func (parser *Parser) declaration() ast.Stmt {
 	var err error
 	var stmt ast.Stmt
-	if parser.match(token.FUN) {
-		stmt, err = parser.functionStatement("function")
+	if parser.match(token.CLASS) {
+		stmt, err = parser.classDeclaration()
+	} else if parser.match(token.FUN) {
+		stmt, err = parser.functionStatement("function", parser.previous().Span.Start)
 	} else if parser.match(token.VAR) {
 		stmt, err = parser.varDeclaration()
+	} else if parser.match(token.IMPORT, token.FROM) {
+		stmt, err = parser.importDeclaration()
 	} else {
 		stmt, err = parser.statement()
 	}
@@ -69,7 +82,7 @@ func (parser *Parser) declaration() ast.Stmt {
 	if err != nil {
 		switch err.(type) {
 		case *loxerror.ParseError:
-			loxerror.ReportError(err)
+			parser.diagnostics.Report(err)
 			parser.synchronize()
 			return nil
 		default:
@@ -80,7 +93,54 @@ func (parser *Parser) declaration() ast.Stmt {
 	return stmt
 }
 
+func (parser *Parser) classDeclaration() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
+	name, err := parser.consume(token.IDENTIFIER, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *ast.VariableExpr = nil
+	if parser.match(token.LESS) {
+		superclassName, err := parser.consume(token.IDENTIFIER, "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
+		superclass = &ast.VariableExpr{Name: superclassName}
+		parser.finish(superclass, superclassName.Span.Start)
+	}
+
+	_, err = parser.consume(token.LEFT_BRACE, "Expect '{' before class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []*ast.FunctionStmt
+	for !parser.check(token.RIGHT_BRACE) && !parser.isAtEnd() {
+		method, err := parser.functionStatement("method", parser.peek().Span.Start)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method.(*ast.FunctionStmt))
+	}
+
+	_, err = parser.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ast.ClassStmt{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
+}
+
 func (parser *Parser) ifStatement() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	parser.consume(token.LEFT_PAREN, "Expect '(' after if.")
 	condition, err := parser.expression()
 	if err != nil {
@@ -101,14 +161,18 @@ func (parser *Parser) ifStatement() (ast.Stmt, error) {
 		}
 	}
 
-	return &ast.IfStmt{
+	stmt := &ast.IfStmt{
 		Condition:  condition,
 		ThenBranch: thenBranch,
 		ElseBranch: elseBranch,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) printStatement() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	expr, err := parser.expression()
 	if err != nil {
 		return nil, err
@@ -119,30 +183,133 @@ func (parser *Parser) printStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return &ast.PrintStmt{
+	stmt := &ast.PrintStmt{
 		Expression: expr,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) returnStatement() (ast.Stmt, error) {
-    keyword := parser.previous()
-    var value ast.Expr = nil
-    var err error = nil
-    if !parser.check(token.SEMICOLON) {
-        value, err = parser.expression()
-        if err != nil {
-            return nil, err
-        }
-    }
-
-    parser.consume(token.SEMICOLON, "Expect ';' after return value.")
-    return &ast.ReturnStmt{
-        Keyword: keyword,
-        Value: value,
-    }, nil
+	keyword := parser.previous()
+	var value ast.Expr = nil
+	var err error = nil
+	if !parser.check(token.SEMICOLON) {
+		value, err = parser.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parser.consume(token.SEMICOLON, "Expect ';' after return value.")
+	stmt := &ast.ReturnStmt{
+		Keyword: keyword,
+		Value:   value,
+	}
+	parser.finish(stmt, keyword.Span.Start)
+
+	return stmt, nil
+}
+
+func (parser *Parser) loopControlStatement() (ast.Stmt, error) {
+	keyword := parser.previous()
+	_, err := parser.consume(token.SEMICOLON, fmt.Sprintf("Expect ';' after '%s'.", keyword.Lexeme))
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt ast.Stmt
+	if keyword.Type == token.BREAK {
+		stmt = &ast.BreakStmt{Keyword: keyword}
+	} else {
+		stmt = &ast.ContinueStmt{Keyword: keyword}
+	}
+	parser.finish(stmt, keyword.Span.Start)
+
+	return stmt, nil
+}
+
+func (parser *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := parser.previous()
+	value, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = parser.consume(token.SEMICOLON, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ast.ThrowStmt{Keyword: keyword, Value: value}
+	parser.finish(stmt, keyword.Span.Start)
+
+	return stmt, nil
+}
+
+func (parser *Parser) tryStatement() (ast.Stmt, error) {
+	keyword := parser.previous()
+	_, err := parser.consume(token.LEFT_BRACE, "Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := parser.block()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ast.TryStmt{Keyword: keyword, Body: body}
+	if parser.match(token.CATCH) {
+		_, err = parser.consume(token.LEFT_PAREN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.CatchName, err = parser.consume(token.IDENTIFIER, "Expect exception variable name.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = parser.consume(token.RIGHT_PAREN, "Expect ')' after exception variable name.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = parser.consume(token.LEFT_BRACE, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.CatchBody, err = parser.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if parser.match(token.FINALLY) {
+		_, err = parser.consume(token.LEFT_BRACE, "Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.FinallyBody, err = parser.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.CatchBody == nil && stmt.FinallyBody == nil {
+		return nil, loxerror.NewParseError(parser.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+	parser.finish(stmt, keyword.Span.Start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) forStatement() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	_, err := parser.consume(token.LEFT_PAREN, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
@@ -192,26 +359,20 @@ func (parser *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = &ast.BlockStmt{
-			Statements: []ast.Stmt{
-				body,
-				&ast.ExprStmt{
-					Expression: increment,
-				},
-			},
-		}
-	}
-
+	// The desugared statements have no source of their own so they all
+	// share the span of the whole loop.
 	if condition == nil {
 		// Ensure that we loop infinitely in the case of a missing condition
 		condition = &ast.LiteralExpr{Value: true}
+		parser.finish(condition, start)
 	}
 
 	body = &ast.WhileStmt{
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
 	}
+	parser.finish(body, start)
 
 	if initializer != nil {
 		body = &ast.BlockStmt{
@@ -220,12 +381,14 @@ func (parser *Parser) forStatement() (ast.Stmt, error) {
 				body,
 			},
 		}
+		parser.finish(body, start)
 	}
 
 	return body, nil
 }
 
 func (parser *Parser) whileStatement() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	_, err := parser.consume(token.LEFT_PAREN, "Expect '(' after 'while'.")
 	if err != nil {
 		return nil, err
@@ -246,21 +409,28 @@ func (parser *Parser) whileStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return &ast.WhileStmt{
+	stmt := &ast.WhileStmt{
 		Condition: condition,
 		Body:      body,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) blockStatement() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	statements, err := parser.block()
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.BlockStmt{
+	stmt := &ast.BlockStmt{
 		Statements: statements,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) block() ([]ast.Stmt, error) {
@@ -282,6 +452,7 @@ func (parser *Parser) block() ([]ast.Stmt, error) {
 }
 
 func (parser *Parser) expressionStatement() (ast.Stmt, error) {
+	start := parser.peek().Span.Start
 	expr, err := parser.expression()
 	if err != nil {
 		return nil, err
@@ -292,29 +463,55 @@ func (parser *Parser) expressionStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return &ast.ExprStmt{
+	stmt := &ast.ExprStmt{
 		Expression: expr,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
-func (parser *Parser) functionStatement(kind string) (ast.Stmt, error) {
+// functionStatement parses a function or method declaration. The span of the
+// result begins at start so that it covers the 'fun' keyword, if any.
+func (parser *Parser) functionStatement(kind string, start token.Position) (ast.Stmt, error) {
 	name, err := parser.consume(token.IDENTIFIER, fmt.Sprintf("Expect %s name.", kind))
 	if err != nil {
 		return nil, err
 	}
 
 	_, err = parser.consume(token.LEFT_PAREN, fmt.Sprintf("Expect '(' after %s name.", kind))
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, body, err := parser.functionBody(kind)
+	if err != nil {
+		return nil, err
+	}
 
+	stmt := &ast.FunctionStmt{
+		Name:       name,
+		Parameters: parameters,
+		Body:       body,
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
+}
+
+// functionBody parses the parameter list, after its opening parenthesis,
+// and the body of a function.
+func (parser *Parser) functionBody(kind string) ([]token.Token, []ast.Stmt, error) {
 	var parameters []token.Token
 	if !parser.check(token.RIGHT_PAREN) {
 		for {
 			if len(parameters) >= 255 {
-				loxerror.ReportError(loxerror.NewParseError(parser.peek(), "Can't have more than 255 parameters"))
+				parser.diagnostics.Report(loxerror.NewParseError(parser.peek(), "Can't have more than 255 parameters"))
 			}
 
 			parameter, err := parser.consume(token.IDENTIFIER, "Expect parameter name.")
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			parameters = append(parameters, parameter)
 
@@ -324,21 +521,183 @@ func (parser *Parser) functionStatement(kind string) (ast.Stmt, error) {
 		}
 	}
 
-	_, err = parser.consume(token.RIGHT_PAREN, "Expect ')' after parameters")
-	_, err = parser.consume(token.LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body."))
+	_, err := parser.consume(token.RIGHT_PAREN, "Expect ')' after parameters")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = parser.consume(token.LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body.", kind))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	body, err := parser.block()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parameters, body, nil
+}
+
+func (parser *Parser) functionExpression() (ast.Expr, error) {
+	keyword := parser.previous()
+	_, err := parser.consume(token.LEFT_PAREN, "Expect '(' after 'fun'.")
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, body, err := parser.functionBody("function")
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.lambda(keyword, parameters, body), nil
+}
+
+// groupingOrArrow parses what follows a '(' in an expression. Until a ','
+// or a '=>' turns up it can't be told whether this is a grouping or the
+// parameter list of an arrow function, so it is parsed as a grouping and
+// converted if it turns out to be parameters.
+func (parser *Parser) groupingOrArrow() (ast.Expr, error) {
+	paren := parser.previous()
+	if parser.match(token.RIGHT_PAREN) {
+		return parser.arrowFunction(paren, nil)
+	}
+
+	expr, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if variable, isVariable := expr.(*ast.VariableExpr); isVariable && parser.match(token.COMMA) {
+		parameters := []token.Token{variable.Name}
+		for {
+			if len(parameters) >= 255 {
+				parser.diagnostics.Report(loxerror.NewParseError(parser.peek(), "Can't have more than 255 parameters"))
+			}
+
+			parameter, err := parser.consume(token.IDENTIFIER, "Expect parameter name.")
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, parameter)
+
+			if !parser.match(token.COMMA) {
+				break
+			}
+		}
+
+		_, err = parser.consume(token.RIGHT_PAREN, "Expect ')' after parameters")
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.arrowFunction(paren, parameters)
+	}
+
+	_, err = parser.consume(token.RIGHT_PAREN, "Expect ')' after expression")
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.check(token.ARROW) {
+		variable, isVariable := expr.(*ast.VariableExpr)
+		if !isVariable {
+			return nil, loxerror.NewParseError(parser.peek(), "Arrow function parameters must be names.")
+		}
+
+		return parser.arrowFunction(paren, []token.Token{variable.Name})
+	}
+
+	return &ast.GroupingExpr{Expression: expr}, nil
+}
+
+// arrowFunction parses the rest of (a, b) => a + b once the parameters have
+// been read. The body may also be a block, as in (a) => { ... }.
+func (parser *Parser) arrowFunction(paren token.Token, parameters []token.Token) (ast.Expr, error) {
+	arrow, err := parser.consume(token.ARROW, "Expect '=>' after arrow function parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.match(token.LEFT_BRACE) {
+		body, err := parser.block()
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.lambda(paren, parameters, body), nil
+	}
+
+	start := parser.peek().Span.Start
+	value, err := parser.assignment()
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.FunctionStmt{
+	body := &ast.ReturnStmt{Keyword: arrow, Value: value}
+	parser.finish(body, start)
+
+	return parser.lambda(paren, parameters, []ast.Stmt{body}), nil
+}
+
+func (parser *Parser) lambda(keyword token.Token, parameters []token.Token, body []ast.Stmt) *ast.FunctionExpr {
+	name := keyword
+	name.Type = token.IDENTIFIER
+	name.Lexeme = "lambda"
+
+	declaration := &ast.FunctionStmt{
 		Name:       name,
 		Parameters: parameters,
 		Body:       body,
-	}, nil
+	}
+	parser.finish(declaration, keyword.Span.Start)
+
+	return &ast.FunctionExpr{Keyword: keyword, Declaration: declaration}
+}
+
+func (parser *Parser) importDeclaration() (ast.Stmt, error) {
+	keyword := parser.previous()
+	path, err := parser.consume(token.STRING, "Expect module path string.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ast.ImportStmt{Keyword: keyword, Path: path}
+	if keyword.Type == token.IMPORT {
+		if _, err := parser.consume(token.AS, "Expect 'as' after module path."); err != nil {
+			return nil, err
+		}
+		if stmt.Alias, err = parser.consume(token.IDENTIFIER, "Expect module name after 'as'."); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := parser.consume(token.IMPORT, "Expect 'import' after module path."); err != nil {
+			return nil, err
+		}
+		for {
+			name, err := parser.consume(token.IDENTIFIER, "Expect name to import.")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Names = append(stmt.Names, name)
+
+			if !parser.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	if _, err := parser.consume(token.SEMICOLON, "Expect ';' after import."); err != nil {
+		return nil, err
+	}
+	parser.finish(stmt, keyword.Span.Start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) varDeclaration() (ast.Stmt, error) {
+	start := parser.previous().Span.Start
 	name, err := parser.consume(token.IDENTIFIER, "Expect variable name.")
 	if err != nil {
 		return nil, err
@@ -357,10 +716,13 @@ func (parser *Parser) varDeclaration() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return &ast.VarStmt{
+	stmt := &ast.VarStmt{
 		Name:        name,
 		Initializer: initializer,
-	}, nil
+	}
+	parser.finish(stmt, start)
+
+	return stmt, nil
 }
 
 func (parser *Parser) assignment() (ast.Expr, error) {
@@ -379,14 +741,33 @@ func (parser *Parser) assignment() (ast.Expr, error) {
 		switch v := expr.(type) {
 		case *ast.VariableExpr:
 			name := v.Name
-			return &ast.AssignExpr{
+			assign := &ast.AssignExpr{
 				Name:  name,
 				Value: value,
-			}, nil
+			}
+			parser.finish(assign, expr.Span().Start)
+			return assign, nil
+		case *ast.GetExpr:
+			set := &ast.SetExpr{
+				Object: v.Object,
+				Name:   v.Name,
+				Value:  value,
+			}
+			parser.finish(set, expr.Span().Start)
+			return set, nil
+		case *ast.IndexExpr:
+			set := &ast.IndexSetExpr{
+				Object:  v.Object,
+				Bracket: v.Bracket,
+				Index:   v.Index,
+				Value:   value,
+			}
+			parser.finish(set, expr.Span().Start)
+			return set, nil
 		}
 
 		err = loxerror.NewParseError(equals, "Invalid assignment target.")
-		loxerror.ReportError(err)
+		parser.diagnostics.Report(err)
 	}
 
 	return expr, nil
@@ -405,11 +786,13 @@ func (parser *Parser) or() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.LogicalExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -428,11 +811,13 @@ func (parser *Parser) and() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.LogicalExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -451,11 +836,13 @@ func (parser *Parser) equality() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.BinaryExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -474,11 +861,13 @@ func (parser *Parser) comparison() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.BinaryExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -497,11 +886,13 @@ func (parser *Parser) term() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.BinaryExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -520,11 +911,13 @@ func (parser *Parser) factor() (ast.Expr, error) {
 			return nil, err
 		}
 
+		start := expr.Span().Start
 		expr = &ast.BinaryExpr{
 			Operator: operator,
 			Left:     expr,
 			Right:    right,
 		}
+		parser.finish(expr, start)
 	}
 
 	return expr, nil
@@ -538,10 +931,13 @@ func (parser *Parser) unary() (ast.Expr, error) {
 			return nil, err
 		}
 
-		return &ast.UnaryExpr{
+		expr := &ast.UnaryExpr{
 			Operator: operator,
 			Right:    right,
-		}, nil
+		}
+		parser.finish(expr, operator.Span.Start)
+
+		return expr, nil
 	}
 
 	return parser.call()
@@ -553,13 +949,72 @@ func (parser *Parser) call() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for parser.match(token.LEFT_PAREN) {
-		expr, err = parser.finish_call(expr)
+	for {
+		if parser.match(token.LEFT_PAREN) {
+			expr, err = parser.finish_call(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if parser.match(token.DOT) {
+			name, err := parser.consume(token.IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+
+			start := expr.Span().Start
+			expr = &ast.GetExpr{
+				Object: expr,
+				Name:   name,
+			}
+			parser.finish(expr, start)
+		} else if parser.match(token.LEFT_BRACKET) {
+			expr, err = parser.finishIndex(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			break
+		}
+	}
+
+	return expr, nil
+}
+
+// finishIndex parses the rest of xs[index] or the slice xs[start:end], in
+// which both bounds are optional.
+func (parser *Parser) finishIndex(object ast.Expr) (ast.Expr, error) {
+	bracket := parser.previous()
+
+	var index ast.Expr
+	var err error
+	if !parser.check(token.COLON) {
+		index, err = parser.expression()
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var expr ast.Expr
+	if parser.match(token.COLON) {
+		var end ast.Expr
+		if !parser.check(token.RIGHT_BRACKET) {
+			end, err = parser.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		expr = &ast.SliceExpr{Object: object, Bracket: bracket, Start: index, End: end}
+	} else {
+		expr = &ast.IndexExpr{Object: object, Bracket: bracket, Index: index}
+	}
+
+	_, err = parser.consume(token.RIGHT_BRACKET, "Expect ']' after index.")
+	if err != nil {
+		return nil, err
+	}
+
+	parser.finish(expr, object.Span().Start)
 	return expr, nil
 }
 
@@ -567,14 +1022,15 @@ func (parser *Parser) finish_call(callee ast.Expr) (ast.Expr, error) {
 	var arguments []ast.Expr
 	if !parser.check(token.RIGHT_PAREN) {
 		for {
+			if len(arguments) >= 255 {
+				parser.diagnostics.Report(loxerror.NewParseError(parser.peek(), "Can't have more than 255 arguments."))
+			}
+
 			arg, err := parser.expression()
 			if err != nil {
 				return nil, err
 			}
 			arguments = append(arguments, arg)
-			if len(arguments) >= 255 {
-				loxerror.ReportError(loxerror.NewParseError(parser.peek(), "Can't have more than 255 arguments."))
-			}
 
 			if !parser.match(token.COMMA) {
 				break
@@ -587,10 +1043,24 @@ func (parser *Parser) finish_call(callee ast.Expr) (ast.Expr, error) {
 		return nil, err
 	}
 
-	return &ast.CallExpr{Callee: callee, Paren: paren, Arguments: arguments}, nil
+	expr := &ast.CallExpr{Callee: callee, Paren: paren, Arguments: arguments}
+	parser.finish(expr, callee.Span().Start)
+
+	return expr, nil
 }
 
 func (parser *Parser) primary() (ast.Expr, error) {
+	start := parser.peek().Span.Start
+	expr, err := parser.primaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.finish(expr, start)
+	return expr, nil
+}
+
+func (parser *Parser) primaryExpr() (ast.Expr, error) {
 	if parser.match(token.FALSE) {
 		return &ast.LiteralExpr{Value: false}, nil
 	}
@@ -605,28 +1075,169 @@ func (parser *Parser) primary() (ast.Expr, error) {
 		return &ast.LiteralExpr{Value: parser.previous().Literal}, nil
 	}
 
-	if parser.match(token.IDENTIFIER) {
-		return &ast.VariableExpr{Name: parser.previous()}, nil
+	if parser.match(token.INTERPOLATION) {
+		return parser.interpolation()
 	}
 
-	if parser.match(token.LEFT_PAREN) {
-		expr, err := parser.expression()
+	if parser.match(token.SUPER) {
+		keyword := parser.previous()
+		_, err := parser.consume(token.DOT, "Expect '.' after 'super'.")
 		if err != nil {
 			return nil, err
 		}
 
-		_, err = parser.consume(token.RIGHT_PAREN, "Expect ')' after expression")
+		method, err := parser.consume(token.IDENTIFIER, "Expect superclass method name.")
 		if err != nil {
 			return nil, err
 		}
 
-		return &ast.GroupingExpr{Expression: expr}, nil
+		return &ast.SuperExpr{Keyword: keyword, Method: method}, nil
+	}
+
+	if parser.match(token.THIS) {
+		return &ast.ThisExpr{Keyword: parser.previous()}, nil
+	}
+
+	if parser.match(token.IDENTIFIER) {
+		return &ast.VariableExpr{Name: parser.previous()}, nil
+	}
+
+	if parser.match(token.LEFT_BRACKET) {
+		return parser.list()
+	}
+
+	// Statements starting with '{' are blocks, so a brace only begins a map
+	// once we are already parsing an expression.
+	if parser.match(token.LEFT_BRACE) {
+		return parser.mapLiteral()
+	}
+
+	if parser.match(token.FUN) {
+		return parser.functionExpression()
+	}
+
+	if parser.match(token.LEFT_PAREN) {
+		return parser.groupingOrArrow()
 	}
 
 	err := loxerror.NewParseError(parser.peek(), "Expect expression")
 	return nil, err
 }
 
+func (parser *Parser) interpolation() (ast.Expr, error) {
+	var parts []ast.Expr
+	for {
+		parts = parser.appendSegment(parts, parser.previous())
+
+		// The rest of the string starts at the '}', so finding it straight
+		// away means the braces were empty.
+		if next := parser.peek(); next.Type == token.STRING || next.Type == token.INTERPOLATION {
+			if strings.HasPrefix(next.Lexeme, "}") {
+				return nil, loxerror.NewParseError(next, "Expect expression inside '${}'.")
+			}
+		}
+
+		expr, err := parser.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+
+		if !parser.match(token.INTERPOLATION) {
+			break
+		}
+	}
+
+	end, err := parser.consume(token.STRING, "Expect '}' after interpolated expression.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.InterpolationExpr{Parts: parser.appendSegment(parts, end)}, nil
+}
+
+// appendSegment adds the text of a string token to the parts of an
+// interpolation, leaving out empty text.
+func (parser *Parser) appendSegment(parts []ast.Expr, segment token.Token) []ast.Expr {
+	if segment.Literal == "" {
+		return parts
+	}
+
+	literal := &ast.LiteralExpr{Value: segment.Literal}
+	literal.SetSpan(segment.Span)
+	return append(parts, literal)
+}
+
+// finish sets the span of a newly parsed node to run from start to the end
+// of the last token consumed.
+func (parser *Parser) finish(node interface{ SetSpan(span token.Span) }, start token.Position) {
+	node.SetSpan(token.Span{Start: start, End: parser.previous().Span.End})
+}
+
+func (parser *Parser) list() (ast.Expr, error) {
+	bracket := parser.previous()
+
+	var elements []ast.Expr
+	if !parser.check(token.RIGHT_BRACKET) {
+		for {
+			element, err := parser.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+
+			if !parser.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := parser.consume(token.RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ListExpr{Bracket: bracket, Elements: elements}, nil
+}
+
+func (parser *Parser) mapLiteral() (ast.Expr, error) {
+	brace := parser.previous()
+
+	var keys, values []ast.Expr
+	if !parser.check(token.RIGHT_BRACE) {
+		for {
+			key, err := parser.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = parser.consume(token.COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := parser.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+
+			if !parser.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := parser.consume(token.RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MapExpr{Brace: brace, Keys: keys, Values: values}, nil
+}
+
 func (parser *Parser) synchronize() {
 	parser.advance()
 
@@ -643,7 +1254,11 @@ func (parser *Parser) synchronize() {
 			token.IF,
 			token.WHILE,
 			token.PRINT,
-			token.RETURN:
+			token.RETURN,
+			token.THROW,
+			token.TRY,
+			token.IMPORT,
+			token.FROM:
 			return
 		}
 
@@ -656,7 +1271,12 @@ func (parser *Parser) consume(tokenType token.TokenType, errorMessage string) (t
 		return parser.advance(), nil
 	}
 
-	return token.Token{Type: token.ERROR, Lexeme: "", Literal: nil, Line: -1}, loxerror.NewParseError(parser.peek(), errorMessage)
+	err := loxerror.NewParseError(parser.peek(), errorMessage)
+	if tokenType == token.SEMICOLON {
+		err.WithHelp("insert a ';' before this token")
+	}
+
+	return token.Token{Type: token.ERROR, Lexeme: "", Literal: nil, Line: -1}, err
 }
 
 func (parser *Parser) match(types ...token.TokenType) bool {
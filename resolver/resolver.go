@@ -0,0 +1,439 @@
+package resolver
+
+import (
+	"github.com/jordanwebster/golox/ast"
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/token"
+)
+
+// Interpreter is notified of the scope depth of every local variable
+// reference so that it can look the variable up by distance at runtime.
+type Interpreter interface {
+	Resolve(expr ast.Expr, depth int)
+}
+
+type functionType int
+
+const (
+	functionTypeNone functionType = iota
+	functionTypeFunction
+	functionTypeInitializer
+	functionTypeMethod
+)
+
+type classType int
+
+const (
+	classTypeNone classType = iota
+	classTypeClass
+	classTypeSubclass
+)
+
+type Resolver struct {
+	interpreter     Interpreter
+	scopes          []map[string]bool
+	currentFunction functionType
+	currentClass    classType
+	// loopDepth counts the loops enclosing the current statement within the
+	// current function.
+	loopDepth   int
+	diagnostics *loxerror.Diagnostics
+}
+
+func NewResolver(interpreter Interpreter, diagnostics *loxerror.Diagnostics) *Resolver {
+	return &Resolver{
+		interpreter:     interpreter,
+		scopes:          make([]map[string]bool, 0, 8),
+		currentFunction: functionTypeNone,
+		currentClass:    classTypeNone,
+		diagnostics:     diagnostics,
+	}
+}
+
+func (resolver *Resolver) Resolve(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		resolver.resolveStmt(stmt)
+	}
+}
+
+func (resolver *Resolver) VisitBlockStmt(stmt *ast.BlockStmt) error {
+	resolver.beginScope()
+	resolver.Resolve(stmt.Statements)
+	resolver.endScope()
+	return nil
+}
+
+func (resolver *Resolver) VisitClassStmt(stmt *ast.ClassStmt) error {
+	enclosingClass := resolver.currentClass
+	resolver.currentClass = classTypeClass
+	defer func() {
+		resolver.currentClass = enclosingClass
+	}()
+
+	resolver.declare(stmt.Name)
+	resolver.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
+			resolver.reportError(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		resolver.currentClass = classTypeSubclass
+		resolver.resolveExpr(stmt.Superclass)
+
+		resolver.beginScope()
+		resolver.peekScope()["super"] = true
+	}
+
+	resolver.beginScope()
+	resolver.peekScope()["this"] = true
+
+	for _, method := range stmt.Methods {
+		declaration := functionTypeMethod
+		if method.Name.Lexeme == "init" {
+			declaration = functionTypeInitializer
+		}
+		resolver.resolveFunction(method, declaration)
+	}
+
+	resolver.endScope()
+
+	if stmt.Superclass != nil {
+		resolver.endScope()
+	}
+
+	return nil
+}
+
+func (resolver *Resolver) VisitExprStmt(stmt *ast.ExprStmt) error {
+	resolver.resolveExpr(stmt.Expression)
+	return nil
+}
+
+func (resolver *Resolver) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	// Define the name eagerly so that the function can refer to itself
+	// recursively inside its own body.
+	resolver.declare(stmt.Name)
+	resolver.define(stmt.Name)
+
+	resolver.resolveFunction(stmt, functionTypeFunction)
+	return nil
+}
+
+func (resolver *Resolver) VisitFunctionExpr(expr *ast.FunctionExpr) (interface{}, error) {
+	resolver.resolveFunction(expr.Declaration, functionTypeFunction)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitIfStmt(stmt *ast.IfStmt) error {
+	resolver.resolveExpr(stmt.Condition)
+	resolver.resolveStmt(stmt.ThenBranch)
+	if stmt.ElseBranch != nil {
+		resolver.resolveStmt(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (resolver *Resolver) VisitPrintStmt(stmt *ast.PrintStmt) error {
+	resolver.resolveExpr(stmt.Expression)
+	return nil
+}
+
+func (resolver *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	if resolver.currentFunction == functionTypeNone {
+		resolver.reportError(stmt.Keyword, "Can't return from top-level code.", "'return' can only be used inside a function")
+	}
+
+	if stmt.Value != nil {
+		if resolver.currentFunction == functionTypeInitializer {
+			resolver.reportError(stmt.Keyword, "Can't return a value from an initializer.")
+		}
+		resolver.resolveExpr(stmt.Value)
+	}
+
+	return nil
+}
+
+func (resolver *Resolver) VisitVarStmt(stmt *ast.VarStmt) error {
+	resolver.declare(stmt.Name)
+	if stmt.Initializer != nil {
+		resolver.resolveExpr(stmt.Initializer)
+	}
+	resolver.define(stmt.Name)
+	return nil
+}
+
+func (resolver *Resolver) VisitImportStmt(stmt *ast.ImportStmt) error {
+	if stmt.Names == nil {
+		resolver.declare(stmt.Alias)
+		resolver.define(stmt.Alias)
+		return nil
+	}
+
+	for _, name := range stmt.Names {
+		resolver.declare(name)
+		resolver.define(name)
+	}
+	return nil
+}
+
+func (resolver *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	resolver.resolveExpr(stmt.Condition)
+
+	resolver.loopDepth++
+	resolver.resolveStmt(stmt.Body)
+	resolver.loopDepth--
+
+	if stmt.Increment != nil {
+		resolver.resolveExpr(stmt.Increment)
+	}
+	return nil
+}
+
+func (resolver *Resolver) VisitBreakStmt(stmt *ast.BreakStmt) error {
+	if resolver.loopDepth == 0 {
+		resolver.reportError(stmt.Keyword, "Can't use 'break' outside of a loop.")
+	}
+	return nil
+}
+
+func (resolver *Resolver) VisitContinueStmt(stmt *ast.ContinueStmt) error {
+	if resolver.loopDepth == 0 {
+		resolver.reportError(stmt.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+	return nil
+}
+
+func (resolver *Resolver) VisitThrowStmt(stmt *ast.ThrowStmt) error {
+	resolver.resolveExpr(stmt.Value)
+	return nil
+}
+
+func (resolver *Resolver) VisitTryStmt(stmt *ast.TryStmt) error {
+	resolver.beginScope()
+	resolver.Resolve(stmt.Body)
+	resolver.endScope()
+
+	if stmt.CatchBody != nil {
+		resolver.beginScope()
+		resolver.declare(stmt.CatchName)
+		resolver.define(stmt.CatchName)
+		resolver.Resolve(stmt.CatchBody)
+		resolver.endScope()
+	}
+
+	if stmt.FinallyBody != nil {
+		resolver.beginScope()
+		resolver.Resolve(stmt.FinallyBody)
+		resolver.endScope()
+	}
+
+	return nil
+}
+
+func (resolver *Resolver) VisitAssignExpr(expr *ast.AssignExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Value)
+	resolver.resolveLocal(expr, expr.Name)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitBinaryExpr(expr *ast.BinaryExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Left)
+	resolver.resolveExpr(expr.Right)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitCallExpr(expr *ast.CallExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		resolver.resolveExpr(argument)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitGetExpr(expr *ast.GetExpr) (interface{}, error) {
+	// Properties are looked up dynamically so only the object is resolved.
+	resolver.resolveExpr(expr.Object)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitGroupingExpr(expr *ast.GroupingExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Expression)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitLiteralExpr(expr *ast.LiteralExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitListExpr(expr *ast.ListExpr) (interface{}, error) {
+	for _, element := range expr.Elements {
+		resolver.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitMapExpr(expr *ast.MapExpr) (interface{}, error) {
+	for i := range expr.Keys {
+		resolver.resolveExpr(expr.Keys[i])
+		resolver.resolveExpr(expr.Values[i])
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitInterpolationExpr(expr *ast.InterpolationExpr) (interface{}, error) {
+	for _, part := range expr.Parts {
+		resolver.resolveExpr(part)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitIndexExpr(expr *ast.IndexExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Object)
+	resolver.resolveExpr(expr.Index)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitIndexSetExpr(expr *ast.IndexSetExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Object)
+	resolver.resolveExpr(expr.Index)
+	resolver.resolveExpr(expr.Value)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitSliceExpr(expr *ast.SliceExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Object)
+	if expr.Start != nil {
+		resolver.resolveExpr(expr.Start)
+	}
+	if expr.End != nil {
+		resolver.resolveExpr(expr.End)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitLogicalExpr(expr *ast.LogicalExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Left)
+	resolver.resolveExpr(expr.Right)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitSetExpr(expr *ast.SetExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Value)
+	resolver.resolveExpr(expr.Object)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	if resolver.currentClass == classTypeNone {
+		resolver.reportError(expr.Keyword, "Can't use 'super' outside of a class.")
+	} else if resolver.currentClass != classTypeSubclass {
+		resolver.reportError(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+	}
+
+	resolver.resolveLocal(expr, expr.Keyword)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	if resolver.currentClass == classTypeNone {
+		resolver.reportError(expr.Keyword, "Can't use 'this' outside of a class.", "'this' refers to the instance a method was called on")
+		return nil, nil
+	}
+
+	resolver.resolveLocal(expr, expr.Keyword)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitUnaryExpr(expr *ast.UnaryExpr) (interface{}, error) {
+	resolver.resolveExpr(expr.Right)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitVariableExpr(expr *ast.VariableExpr) (interface{}, error) {
+	if len(resolver.scopes) > 0 {
+		if defined, isDeclared := resolver.peekScope()[expr.Name.Lexeme]; isDeclared && !defined {
+			resolver.reportError(expr.Name, "Can't read local variable in its own initializer.", "give the new variable a different name")
+		}
+	}
+
+	resolver.resolveLocal(expr, expr.Name)
+	return nil, nil
+}
+
+func (resolver *Resolver) resolveStmt(stmt ast.Stmt) {
+	stmt.Accept(resolver)
+}
+
+func (resolver *Resolver) resolveExpr(expr ast.Expr) {
+	expr.Accept(resolver)
+}
+
+func (resolver *Resolver) resolveFunction(function *ast.FunctionStmt, kind functionType) {
+	enclosingFunction := resolver.currentFunction
+	enclosingLoopDepth := resolver.loopDepth
+	resolver.currentFunction = kind
+	// A loop around the declaration doesn't let the body break out of it.
+	resolver.loopDepth = 0
+	defer func() {
+		resolver.currentFunction = enclosingFunction
+		resolver.loopDepth = enclosingLoopDepth
+	}()
+
+	resolver.beginScope()
+	for _, param := range function.Parameters {
+		resolver.declare(param)
+		resolver.define(param)
+	}
+	resolver.Resolve(function.Body)
+	resolver.endScope()
+}
+
+// resolveLocal tells the interpreter how many scopes lie between the
+// reference and the declaration. Variables that aren't found in any scope
+// are left unresolved and assumed to be global.
+func (resolver *Resolver) resolveLocal(expr ast.Expr, name token.Token) {
+	for i := len(resolver.scopes) - 1; i >= 0; i-- {
+		if _, isPresent := resolver.scopes[i][name.Lexeme]; isPresent {
+			resolver.interpreter.Resolve(expr, len(resolver.scopes)-1-i)
+			return
+		}
+	}
+}
+
+func (resolver *Resolver) beginScope() {
+	resolver.scopes = append(resolver.scopes, make(map[string]bool))
+}
+
+func (resolver *Resolver) endScope() {
+	resolver.scopes = resolver.scopes[:len(resolver.scopes)-1]
+}
+
+func (resolver *Resolver) peekScope() map[string]bool {
+	return resolver.scopes[len(resolver.scopes)-1]
+}
+
+func (resolver *Resolver) declare(name token.Token) {
+	if len(resolver.scopes) == 0 {
+		return
+	}
+
+	scope := resolver.peekScope()
+	if _, isPresent := scope[name.Lexeme]; isPresent {
+		resolver.reportError(name, "Already a variable with this name in this scope.")
+	}
+
+	scope[name.Lexeme] = false
+}
+
+func (resolver *Resolver) define(name token.Token) {
+	if len(resolver.scopes) == 0 {
+		return
+	}
+
+	resolver.peekScope()[name.Lexeme] = true
+}
+
+func (resolver *Resolver) reportError(name token.Token, message string, help ...string) {
+	resolver.diagnostics.Report(loxerror.NewParseError(name, message).WithHelp(help...))
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/jordanwebster/golox/loxerror"
 	"github.com/jordanwebster/golox/loxio"
@@ -12,52 +14,81 @@ import (
 )
 
 var keywords map[string]token.TokenType = map[string]token.TokenType{
-	"and":    token.AND,
-	"class":  token.CLASS,
-	"else":   token.ELSE,
-	"false":  token.FALSE,
-	"for":    token.FOR,
-	"fun":    token.FUN,
-	"if":     token.IF,
-	"nil":    token.NIL,
-	"or":     token.OR,
-	"print":  token.PRINT,
-	"return": token.RETURN,
-	"super":  token.SUPER,
-	"this":   token.THIS,
-	"true":   token.TRUE,
-	"var":    token.VAR,
-	"while":  token.WHILE,
+	"and":      token.AND,
+	"as":       token.AS,
+	"break":    token.BREAK,
+	"catch":    token.CATCH,
+	"class":    token.CLASS,
+	"continue": token.CONTINUE,
+	"else":     token.ELSE,
+	"false":    token.FALSE,
+	"finally":  token.FINALLY,
+	"for":      token.FOR,
+	"from":     token.FROM,
+	"fun":      token.FUN,
+	"if":       token.IF,
+	"import":   token.IMPORT,
+	"nil":      token.NIL,
+	"or":       token.OR,
+	"print":    token.PRINT,
+	"return":   token.RETURN,
+	"super":    token.SUPER,
+	"this":     token.THIS,
+	"throw":    token.THROW,
+	"true":     token.TRUE,
+	"try":      token.TRY,
+	"var":      token.VAR,
+	"while":    token.WHILE,
 }
 
 type Scanner struct {
-	reader  *bufio.Reader
-	tokens  chan token.Token
-	current []byte
-	line    int
+	reader   *bufio.Reader
+	tokens   chan token.Token
+	current  []byte
+	start    token.Position
+	position token.Position
+	// Open braces within each "${...}" being scanned, innermost last. The
+	// string resumes at the '}' that closes one with none open.
+	interpolations []int
+	diagnostics    *loxerror.Diagnostics
 }
 
-func reportSyntaxError(line int, message string) {
-	err := loxerror.NewSyntaxError(line, message)
-	loxerror.ReportError(err)
-}
-
-func NewScanner(source io.Reader, tokens chan token.Token) *Scanner {
+func NewScanner(source io.Reader, tokens chan token.Token, diagnostics *loxerror.Diagnostics) *Scanner {
 	reader := bufio.NewReader(source)
 	return &Scanner{
-		reader: reader,
-		tokens: tokens,
-		line:   1,
+		reader:      reader,
+		tokens:      tokens,
+		position:    token.Position{Line: 1, Column: 1},
+		diagnostics: diagnostics,
 	}
 }
 
+// reportSyntaxError reports an error covering the token scanned so far.
+func (scanner *Scanner) reportSyntaxError(message string, help ...string) {
+	scanner.reportSyntaxErrorFrom(scanner.start, message, help...)
+}
+
+// reportSyntaxErrorFrom reports an error covering the text from start up to
+// the current position.
+func (scanner *Scanner) reportSyntaxErrorFrom(start token.Position, message string, help ...string) {
+	span := token.Span{Start: start, End: scanner.position}
+	scanner.diagnostics.Report(loxerror.NewSyntaxErrorAt(span, message).WithHelp(help...))
+}
+
 func (scanner *Scanner) ScanTokens() {
 	for !scanner.isAtEnd() {
 		scanner.current = make([]byte, 0, 4)
+		scanner.start = scanner.position
 		scanner.scanToken()
 	}
 
-	scanner.tokens <- token.Token{Type: token.EOF, Lexeme: "", Literal: nil, Line: scanner.line}
+	scanner.tokens <- token.Token{
+		Type:    token.EOF,
+		Lexeme:  "",
+		Literal: nil,
+		Line:    scanner.position.Line,
+		Span:    token.Span{Start: scanner.position, End: scanner.position},
+	}
 	close(scanner.tokens)
 }
 
@@ -76,9 +107,26 @@ func (scanner *Scanner) scanToken() {
 	case ')':
 		scanner.addToken(token.RIGHT_PAREN)
 	case '{':
+		if depth := len(scanner.interpolations); depth > 0 {
+			scanner.interpolations[depth-1]++
+		}
 		scanner.addToken(token.LEFT_BRACE)
 	case '}':
+		if depth := len(scanner.interpolations); depth > 0 {
+			if scanner.interpolations[depth-1] == 0 {
+				scanner.interpolations = scanner.interpolations[:depth-1]
+				scanner.addString()
+				return
+			}
+			scanner.interpolations[depth-1]--
+		}
 		scanner.addToken(token.RIGHT_BRACE)
+	case '[':
+		scanner.addToken(token.LEFT_BRACKET)
+	case ']':
+		scanner.addToken(token.RIGHT_BRACKET)
+	case ':':
+		scanner.addToken(token.COLON)
 	case ',':
 		scanner.addToken(token.COMMA)
 	case '.':
@@ -100,6 +148,8 @@ func (scanner *Scanner) scanToken() {
 	case '=':
 		if scanner.match('=') {
 			scanner.addToken(token.EQUAL_EQUAL)
+		} else if scanner.match('>') {
+			scanner.addToken(token.ARROW)
 		} else {
 			scanner.addToken(token.EQUAL)
 		}
@@ -130,10 +180,13 @@ func (scanner *Scanner) scanToken() {
 	case '\t':
 
 	case '\n':
-		scanner.line += 1
 
 	case '"':
-		scanner.addString()
+		if scanner.peek() == '"' && scanner.peekNext() == '"' {
+			scanner.addRawString()
+		} else {
+			scanner.addString()
+		}
 
 	default:
 		if scanner.isDigit(c) {
@@ -141,15 +194,29 @@ func (scanner *Scanner) scanToken() {
 		} else if scanner.isAlpha(c) {
 			scanner.addIdentifier()
 		} else {
-			reportSyntaxError(scanner.line, fmt.Sprintf("Unexpected character: %s", c))
+			scanner.reportSyntaxError(fmt.Sprintf("Unexpected character: %s", string(c)))
 		}
 	}
 }
 
 func (scanner *Scanner) advance() byte {
-	byte, _ := scanner.reader.ReadByte()
-	scanner.current = append(scanner.current, byte)
-	return byte
+	c, err := scanner.reader.ReadByte()
+	if err != nil {
+		return 0
+	}
+
+	scanner.current = append(scanner.current, c)
+	scanner.position.Offset += 1
+	if c == '\n' {
+		scanner.position.Line += 1
+		scanner.position.Column = 1
+	} else if !utf8.RuneStart(c) {
+		// Continuation bytes belong to the same column as the rune's first byte.
+	} else {
+		scanner.position.Column += 1
+	}
+
+	return c
 }
 
 func (scanner *Scanner) match(expected byte) bool {
@@ -157,7 +224,7 @@ func (scanner *Scanner) match(expected byte) bool {
 		return false
 	}
 
-	scanner.reader.ReadByte()
+	scanner.advance()
 	return true
 }
 
@@ -179,14 +246,14 @@ func (scanner *Scanner) peek() byte {
 }
 
 func (scanner *Scanner) peekNext() byte {
-	bytes, err := scanner.reader.Peek(1)
+	bytes, err := scanner.reader.Peek(2)
 	if len(bytes) < 2 {
 		if err == io.EOF {
 			return 0
 		} else if err == loxio.Waiting {
 			// This will issue another call to the underlying channel which will
 			// block until more data is received.
-			return scanner.peek()
+			return scanner.peekNext()
 		} else {
 			panic(err)
 		}
@@ -204,28 +271,141 @@ func (scanner *Scanner) addTokenWithLiteral(tokenType token.TokenType, literal i
 		Type:    tokenType,
 		Lexeme:  string(scanner.current),
 		Literal: literal,
-		Line:    scanner.line,
+		Line:    scanner.position.Line,
+		Span:    token.Span{Start: scanner.start, End: scanner.position},
 	}
 	scanner.tokens <- token
 }
 
+// addString scans the rest of a string literal, or of a part of one, after
+// the opening '"' or the '}' that ended an interpolated expression.
 func (scanner *Scanner) addString() {
+	var value strings.Builder
 	for scanner.peek() != '"' && !scanner.isAtEnd() {
-		if scanner.peek() == '\n' {
-			scanner.line += 1
+		if scanner.peek() == '$' && scanner.peekNext() == '{' {
+			scanner.advance()
+			scanner.advance()
+
+			scanner.addTokenWithLiteral(token.INTERPOLATION, value.String())
+			scanner.interpolations = append(scanner.interpolations, 0)
+			return
+		}
+
+		if scanner.peek() == '\\' {
+			scanner.escape(&value)
+		} else {
+			value.WriteByte(scanner.advance())
 		}
-		scanner.advance()
 	}
 
 	if scanner.isAtEnd() {
-		reportSyntaxError(scanner.line, "Unterminated string")
+		scanner.reportSyntaxError("Unterminated string", "close the string with '\"'")
 		return
 	}
 
 	// Consume the closing '"'
 	scanner.advance()
 
-	value := string(scanner.current[1 : len(scanner.current)-1])
+	scanner.addTokenWithLiteral(token.STRING, value.String())
+}
+
+// escape decodes the escape sequence at the current position into value.
+// Invalid sequences are reported and left out.
+func (scanner *Scanner) escape(value *strings.Builder) {
+	start := scanner.position
+	scanner.advance()
+	if scanner.isAtEnd() {
+		// The string is reported as unterminated.
+		return
+	}
+
+	switch c := scanner.advance(); c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '0':
+		value.WriteByte(0)
+	case '\\', '"', '$':
+		value.WriteByte(c)
+	case 'u':
+		scanner.unicodeEscape(value, start)
+	default:
+		// Take the whole character for the message if it is multi-byte.
+		for !utf8.RuneStart(scanner.peek()) && !scanner.isAtEnd() {
+			scanner.advance()
+		}
+		sequence := scanner.textFrom(start)
+		scanner.reportSyntaxErrorFrom(start, fmt.Sprintf("Invalid escape sequence '%s'.", sequence),
+			`valid escapes are \n, \t, \r, \0, \\, \", \$ and \u{...}`)
+	}
+}
+
+// unicodeEscape decodes the \u{1F600} form, after the 'u', which names a
+// code point with one to six hex digits.
+func (scanner *Scanner) unicodeEscape(value *strings.Builder, start token.Position) {
+	const help = `write the code point in hex between braces, as in \u{1F600}`
+	if !scanner.match('{') {
+		scanner.reportSyntaxErrorFrom(start, "Expect '{' after '\\u'.", help)
+		return
+	}
+
+	digits := 0
+	for scanner.isHexDigit(scanner.peek()) {
+		scanner.advance()
+		digits++
+	}
+
+	if !scanner.match('}') {
+		scanner.reportSyntaxErrorFrom(start, "Expect '}' after unicode escape digits.", help)
+		return
+	}
+
+	hex := string(scanner.current[len(scanner.current)-digits-1 : len(scanner.current)-1])
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if digits == 0 || digits > 6 || err != nil || !utf8.ValidRune(rune(code)) {
+		sequence := scanner.textFrom(start)
+		scanner.reportSyntaxErrorFrom(start, fmt.Sprintf("Invalid unicode escape '%s'.", sequence), help)
+		return
+	}
+
+	value.WriteRune(rune(code))
+}
+
+// textFrom returns the source scanned from start up to the current position.
+func (scanner *Scanner) textFrom(start token.Position) string {
+	return string(scanner.current[len(scanner.current)-(scanner.position.Offset-start.Offset):])
+}
+
+func (scanner *Scanner) isHexDigit(c byte) bool {
+	return scanner.isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// addRawString scans a triple-quoted string, which may span lines and
+// contains exactly the text between the quotes, without escapes or
+// interpolation.
+func (scanner *Scanner) addRawString() {
+	// The first '"' has been consumed already.
+	scanner.advance()
+	scanner.advance()
+
+	quotes := 0
+	for quotes < 3 {
+		if scanner.isAtEnd() {
+			scanner.reportSyntaxError("Unterminated raw string", `close the string with '"""'`)
+			return
+		}
+
+		if scanner.advance() == '"' {
+			quotes++
+		} else {
+			quotes = 0
+		}
+	}
+
+	value := string(scanner.current[3 : len(scanner.current)-3])
 	scanner.addTokenWithLiteral(token.STRING, value)
 }
 func (scanner *Scanner) isDigit(c byte) bool {
@@ -248,7 +428,7 @@ func (scanner *Scanner) addNumber() {
 
 	number, err := strconv.ParseFloat(string(scanner.current), 64)
 	if err != nil {
-		reportSyntaxError(scanner.line, fmt.Sprintf("Unable to parse number to float: %s", string(scanner.current)))
+		scanner.reportSyntaxError(fmt.Sprintf("Unable to parse number to float: %s", string(scanner.current)))
 		return
 	}
 	scanner.addTokenWithLiteral(token.NUMBER, number)
@@ -8,23 +8,27 @@ type TokenType string
 
 const (
 	// Single-character tokens
-	LEFT_PAREN  = "("
-	RIGHT_PAREN = ")"
-	LEFT_BRACE  = "{"
-	RIGHT_BRACE = "}"
-	COMMA       = ","
-	DOT         = "."
-	MINUS       = "-"
-	PLUS        = "+"
-	SEMICOLON   = ";"
-	SLASH       = "/"
-	STAR        = "*"
+	LEFT_PAREN    = "("
+	RIGHT_PAREN   = ")"
+	LEFT_BRACE    = "{"
+	RIGHT_BRACE   = "}"
+	LEFT_BRACKET  = "["
+	RIGHT_BRACKET = "]"
+	COLON         = ":"
+	COMMA         = ","
+	DOT           = "."
+	MINUS         = "-"
+	PLUS          = "+"
+	SEMICOLON     = ";"
+	SLASH         = "/"
+	STAR          = "*"
 
 	// One or two character tokens
 	BANG          = "!"
 	BANG_EQUAL    = "!="
 	EQUAL         = "="
 	EQUAL_EQUAL   = "=="
+	ARROW         = "=>"
 	GREATER       = ">"
 	GREATER_EQUAL = ">="
 	LESS          = "<"
@@ -33,36 +37,81 @@ const (
 	// Literals
 	IDENTIFIER = "IDENTIFIER"
 	STRING     = "STRING"
-	NUMBER     = "NUMBER"
+	// INTERPOLATION is the part of a string literal up to a "${". Its
+	// literal is the text of that part. The remainder of the string follows
+	// the embedded expression as another INTERPOLATION or, for the last
+	// part, a STRING.
+	INTERPOLATION = "INTERPOLATION"
+	NUMBER        = "NUMBER"
 
 	// Keywords
-	AND    = "AND"
-	CLASS  = "CLASS"
-	ELSE   = "ELSE"
-	FALSE  = "FALSE"
-	FOR    = "FOR"
-	FUN    = "FUN"
-	IF     = "IF"
-	NIL    = "NIL"
-	OR     = "OR"
-	PRINT  = "PRINT"
-	RETURN = "RETURN"
-	SUPER  = "SUPER"
-	THIS   = "THIS"
-	TRUE   = "TRUE"
-	VAR    = "VAR"
-	WHILE  = "WHILE"
+	AND      = "AND"
+	AS       = "AS"
+	BREAK    = "BREAK"
+	CATCH    = "CATCH"
+	CLASS    = "CLASS"
+	CONTINUE = "CONTINUE"
+	ELSE     = "ELSE"
+	FALSE    = "FALSE"
+	FINALLY  = "FINALLY"
+	FOR      = "FOR"
+	FROM     = "FROM"
+	FUN      = "FUN"
+	IF       = "IF"
+	IMPORT   = "IMPORT"
+	NIL      = "NIL"
+	OR       = "OR"
+	PRINT    = "PRINT"
+	RETURN   = "RETURN"
+	SUPER    = "SUPER"
+	THIS     = "THIS"
+	THROW    = "THROW"
+	TRUE     = "TRUE"
+	TRY      = "TRY"
+	VAR      = "VAR"
+	WHILE    = "WHILE"
 
 	EOF = "EOF"
 
-    ERROR = "ERROR"
+	ERROR = "ERROR"
 )
 
+// Position is a location in the source. Offset counts bytes from the start
+// of the input, while Line and Column start at 1 and Column counts runes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (position Position) String() string {
+	return fmt.Sprintf("%d:%d", position.Line, position.Column)
+}
+
+// Span is the range of source a token or syntax node was read from. End is
+// the position just past the last character.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Len is the length of the span in bytes.
+func (span Span) Len() int {
+	return span.End.Offset - span.Start.Offset
+}
+
+// IsValid reports whether the span was set by the scanner or parser, as
+// opposed to being the zero value of a synthesized token.
+func (span Span) IsValid() bool {
+	return span.Start.Line > 0
+}
+
 type Token struct {
 	Type    TokenType
 	Lexeme  string
 	Literal interface{}
 	Line    int
+	Span    Span
 }
 
 func (token *Token) String() string {
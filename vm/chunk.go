@@ -0,0 +1,120 @@
+package vm
+
+import (
+	"sort"
+
+	"github.com/jordanwebster/golox/token"
+)
+
+type OpCode byte
+
+// Operands follow the opcode in the instruction stream. Constant and global
+// name indexes and jump offsets are two bytes, big-endian; local and upvalue
+// slots and argument counts are one byte.
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+	OpGetLocal
+	OpSetLocal
+	OpGetGlobal
+	OpDefineGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpGetProperty
+	OpSetProperty
+	OpGetSuper
+	OpBuildList
+	OpBuildMap
+	OpInterpolate
+	OpGetIndex
+	OpSetIndex
+	OpSlice
+	OpEqual
+	OpNotEqual
+	OpGreater
+	OpGreaterEqual
+	OpLess
+	OpLessEqual
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpNot
+	OpNegate
+	OpPrint
+	OpJump
+	OpJumpIfFalse
+	OpLoop
+	OpCall
+	OpClosure
+	OpCloseUpvalue
+	OpReturn
+	OpThrow
+	OpTry
+	OpTryFinally
+	OpEndTry
+	OpClass
+	OpInherit
+	OpMethod
+	OpImport
+)
+
+type positionStart struct {
+	offset int
+	line   int
+	span   token.Span
+}
+
+// Chunk is a sequence of bytecode along with the constants it references.
+// Source positions are stored run-length encoded since consecutive
+// instructions almost always come from the same token.
+type Chunk struct {
+	Code      []byte
+	Constants []Value
+	positions []positionStart
+}
+
+// Write appends a byte of code that came from the token at line and span.
+func (chunk *Chunk) Write(b byte, line int, span token.Span) {
+	chunk.Code = append(chunk.Code, b)
+
+	if len(chunk.positions) > 0 {
+		last := chunk.positions[len(chunk.positions)-1]
+		if last.line == line && last.span == span {
+			return
+		}
+	}
+
+	chunk.positions = append(chunk.positions, positionStart{offset: len(chunk.Code) - 1, line: line, span: span})
+}
+
+func (chunk *Chunk) AddConstant(value Value) int {
+	chunk.Constants = append(chunk.Constants, value)
+	return len(chunk.Constants) - 1
+}
+
+// Line returns the source line of the instruction at the given offset.
+func (chunk *Chunk) Line(offset int) int {
+	return chunk.position(offset).line
+}
+
+// Span returns the span of the token the instruction at the given offset
+// came from.
+func (chunk *Chunk) Span(offset int) token.Span {
+	return chunk.position(offset).span
+}
+
+func (chunk *Chunk) position(offset int) positionStart {
+	i := sort.Search(len(chunk.positions), func(i int) bool {
+		return chunk.positions[i].offset > offset
+	})
+	if i == 0 {
+		return positionStart{}
+	}
+
+	return chunk.positions[i-1]
+}
@@ -0,0 +1,109 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/jordanwebster/golox/loxerror"
+)
+
+// ObjError is the value a catch clause receives when it catches a runtime
+// error raised by the VM rather than by a throw statement.
+type ObjError struct {
+	err *loxerror.RuntimeError
+}
+
+func (e *ObjError) String() string {
+	return e.err.Message()
+}
+
+func errorProperty(e *ObjError, name string) (Value, error) {
+	switch name {
+	case "message":
+		return StringValue(e.err.Message()), nil
+	case "line":
+		return NumberValue(float64(e.err.Token().Line)), nil
+	case "trace":
+		trace := make([]Value, len(e.err.Trace()))
+		for i, frame := range e.err.Trace() {
+			trace[i] = StringValue(frame.String())
+		}
+		return ObjValue(NewList(trace)), nil
+	}
+
+	return Nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+// exception is a thrown value on its way to a handler. err is reported
+// instead if there is no handler to catch it.
+//
+// A finally clause that runs because of an exception holds on to the
+// exception itself, in a slot the program can't name, so that it can be
+// rethrown unchanged once the clause completes.
+type exception struct {
+	value Value
+	err   *loxerror.RuntimeError
+}
+
+func (e *exception) Error() string {
+	return e.err.Error()
+}
+
+func (e *exception) String() string {
+	return e.value.String()
+}
+
+// handler is an active try block. When something is thrown the stack is
+// unwound to the height it had when the block was entered and execution
+// continues at ip in the same frame.
+type handler struct {
+	frameCount int
+	stackTop   int
+	ip         int
+	// Set if the handler runs a finally clause, which receives the
+	// exception, rather than a catch clause, which receives the value.
+	finally bool
+}
+
+func (vm *VM) throw(value Value) error {
+	switch thrown := value.obj.(type) {
+	case *exception:
+		return thrown
+	case *ObjError:
+		// Rethrowing a caught runtime error keeps its original location.
+		return &exception{value: value, err: thrown.err}
+	}
+
+	return &exception{value: value, err: vm.runtimeError("Uncaught exception: %s", value.String())}
+}
+
+// catch transfers control to the innermost handler, if there is one, and
+// reports whether err could be caught. Cancellation can't be.
+func (vm *VM) catch(err error) bool {
+	if len(vm.handlers) == vm.baseHandler {
+		return false
+	}
+
+	var thrown *exception
+	switch err := err.(type) {
+	case *exception:
+		thrown = err
+	case *loxerror.RuntimeError:
+		thrown = &exception{value: ObjValue(&ObjError{err: err}), err: err}
+	default:
+		return false
+	}
+
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.closeUpvalues(handler.stackTop)
+	vm.frameCount = handler.frameCount
+	vm.stackTop = handler.stackTop
+	if handler.finally {
+		vm.push(ObjValue(thrown))
+	} else {
+		vm.push(thrown.value)
+	}
+	vm.frames[vm.frameCount-1].ip = handler.ip
+	return true
+}
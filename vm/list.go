@@ -0,0 +1,205 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+func (list *ObjList) Len() int {
+	return len(list.Elements)
+}
+
+// At returns the element at a position known to be in range, as natives
+// see it.
+func (list *ObjList) At(i int) interface{} {
+	return list.Elements[i].Interface()
+}
+
+// listMethod looks up a built-in method on a list, bound to that list.
+func listMethod(list *ObjList, name string) (*ObjNative, error) {
+	var arity int
+	var function NativeFn
+
+	switch name {
+	case "len":
+		function = func(arguments []Value) (Value, error) {
+			return NumberValue(float64(len(list.Elements))), nil
+		}
+	case "push":
+		arity = 1
+		function = func(arguments []Value) (Value, error) {
+			list.Elements = append(list.Elements, arguments[0])
+			return Nil, nil
+		}
+	case "pop":
+		function = func(arguments []Value) (Value, error) {
+			if len(list.Elements) == 0 {
+				return Nil, errors.New("Can't pop from an empty list.")
+			}
+
+			last := list.Elements[len(list.Elements)-1]
+			list.Elements = list.Elements[:len(list.Elements)-1]
+			return last, nil
+		}
+	case "insert":
+		arity = 2
+		function = func(arguments []Value) (Value, error) {
+			// Inserting at the length appends, so it is a valid position.
+			i, err := listPosition(list, arguments[0], true)
+			if err != nil {
+				return Nil, err
+			}
+
+			list.Elements = append(list.Elements, Nil)
+			copy(list.Elements[i+1:], list.Elements[i:])
+			list.Elements[i] = arguments[1]
+			return Nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("Undefined property '%s'.", name)
+	}
+
+	return &ObjNative{Name: name, Arity: arity, Function: function}, nil
+}
+
+// getIndex implements container[index] for lists and maps.
+func getIndex(container Value, index Value) (Value, error) {
+	switch obj := container.obj.(type) {
+	case *ObjList:
+		i, err := listPosition(obj, index, false)
+		if err != nil {
+			return Nil, err
+		}
+		return obj.Elements[i], nil
+	case *ObjMap:
+		return obj.Get(index)
+	default:
+		return Nil, errors.New("Only lists and maps can be indexed.")
+	}
+}
+
+func setIndex(container Value, index Value, value Value) error {
+	switch obj := container.obj.(type) {
+	case *ObjList:
+		i, err := listPosition(obj, index, false)
+		if err != nil {
+			return err
+		}
+		obj.Elements[i] = value
+		return nil
+	case *ObjMap:
+		return obj.Set(index, value)
+	default:
+		return errors.New("Only lists and maps can be indexed.")
+	}
+}
+
+// listPosition converts a Lox index into a position in the list, counting
+// back from the end if it is negative.
+func listPosition(list *ObjList, index Value, allowEnd bool) (int, error) {
+	if !index.IsNumber() || index.number != math.Trunc(index.number) {
+		return 0, errors.New("List index must be an integer.")
+	}
+
+	// The index is checked before it is converted, as a number too large for
+	// an int would otherwise wrap around.
+	i := index.number
+	if i < 0 {
+		i += float64(len(list.Elements))
+	}
+
+	limit := len(list.Elements)
+	if allowEnd {
+		limit++
+	}
+	if i < 0 || i >= float64(limit) {
+		return 0, fmt.Errorf("List index %.0f is out of range for length %d.", index.number, len(list.Elements))
+	}
+
+	return int(i), nil
+}
+
+// sliceList copies the elements from start up to but not including end.
+// Nil bounds mean the start or end of the list, and bounds outside the list
+// are clamped to it.
+func sliceList(list *ObjList, start Value, end Value) (*ObjList, error) {
+	from, err := sliceBound(list, start, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := sliceBound(list, end, len(list.Elements))
+	if err != nil {
+		return nil, err
+	}
+
+	if to < from {
+		to = from
+	}
+
+	elements := make([]Value, to-from)
+	copy(elements, list.Elements[from:to])
+	return NewList(elements), nil
+}
+
+func sliceBound(list *ObjList, value Value, fallback int) (int, error) {
+	if value.IsNil() {
+		return fallback, nil
+	}
+
+	if !value.IsNumber() || value.number != math.Trunc(value.number) {
+		return 0, errors.New("Slice bounds must be integers.")
+	}
+
+	i := value.number
+	if i < 0 {
+		i += float64(len(list.Elements))
+	}
+
+	if i < 0 {
+		return 0, nil
+	} else if i > float64(len(list.Elements)) {
+		return len(list.Elements), nil
+	}
+
+	return int(i), nil
+}
+
+// repr formats a value as it appears inside a container, where strings are
+// quoted. Containers already being printed are shown as "[...]" or "{...}"
+// so that a list holding itself doesn't recurse forever.
+func repr(value Value, seen map[Obj]bool) string {
+	switch obj := value.obj.(type) {
+	case *ObjString:
+		return fmt.Sprintf("%q", obj.Chars)
+	case *ObjList:
+		if seen[obj] {
+			return "[...]"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		elements := make([]string, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = repr(element, seen)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *ObjMap:
+		if seen[obj] {
+			return "{...}"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		entries := make([]string, len(obj.keys))
+		for i, key := range obj.keys {
+			k, _ := mapKey(key)
+			entries[i] = repr(key, seen) + ": " + repr(obj.values[k], seen)
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	default:
+		return value.String()
+	}
+}
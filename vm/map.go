@@ -0,0 +1,139 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jordanwebster/golox/native"
+)
+
+// ObjMap is a dictionary that remembers the order keys were first added in.
+// Keys are restricted to nil, booleans, numbers and strings and are stored
+// by their Go representation so that equal strings find the same entry.
+type ObjMap struct {
+	keys   []Value
+	values map[interface{}]Value
+}
+
+func NewMap() *ObjMap {
+	return &ObjMap{values: make(map[interface{}]Value)}
+}
+
+func (m *ObjMap) Get(key Value) (Value, error) {
+	k, err := mapKey(key)
+	if err != nil {
+		return Nil, err
+	}
+
+	value, isPresent := m.values[k]
+	if !isPresent {
+		return Nil, fmt.Errorf("Undefined key %s.", repr(key, nil))
+	}
+
+	return value, nil
+}
+
+func (m *ObjMap) Set(key Value, value Value) error {
+	k, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+
+	if _, isPresent := m.values[k]; !isPresent {
+		m.keys = append(m.keys, key)
+	}
+	m.values[k] = value
+	return nil
+}
+
+// Entries lists the entries as natives see them.
+func (m *ObjMap) Entries() []native.Entry {
+	entries := make([]native.Entry, len(m.keys))
+	for i, key := range m.keys {
+		k, _ := mapKey(key)
+		entries[i] = native.Entry{Key: key.Interface(), Value: m.values[k].Interface()}
+	}
+	return entries
+}
+
+func (m *ObjMap) String() string {
+	return repr(ObjValue(m), make(map[Obj]bool))
+}
+
+// mapMethod looks up a built-in method on a map, bound to that map.
+func mapMethod(m *ObjMap, name string) (*ObjNative, error) {
+	var arity int
+	var function NativeFn
+
+	switch name {
+	case "len":
+		function = func(arguments []Value) (Value, error) {
+			return NumberValue(float64(len(m.keys))), nil
+		}
+	case "keys":
+		function = func(arguments []Value) (Value, error) {
+			return ObjValue(NewList(append([]Value(nil), m.keys...))), nil
+		}
+	case "values":
+		function = func(arguments []Value) (Value, error) {
+			values := make([]Value, len(m.keys))
+			for i, key := range m.keys {
+				k, _ := mapKey(key)
+				values[i] = m.values[k]
+			}
+			return ObjValue(NewList(values)), nil
+		}
+	case "has":
+		arity = 1
+		function = func(arguments []Value) (Value, error) {
+			k, err := mapKey(arguments[0])
+			if err != nil {
+				return Nil, err
+			}
+
+			_, isPresent := m.values[k]
+			return BoolValue(isPresent), nil
+		}
+	case "remove":
+		// remove returns the value that was removed, or nil if the key was
+		// not present.
+		arity = 1
+		function = func(arguments []Value) (Value, error) {
+			k, err := mapKey(arguments[0])
+			if err != nil {
+				return Nil, err
+			}
+
+			value, isPresent := m.values[k]
+			if !isPresent {
+				return Nil, nil
+			}
+
+			delete(m.values, k)
+			for i := range m.keys {
+				if key, _ := mapKey(m.keys[i]); key == k {
+					m.keys = append(m.keys[:i], m.keys[i+1:]...)
+					break
+				}
+			}
+			return value, nil
+		}
+	default:
+		return nil, fmt.Errorf("Undefined property '%s'.", name)
+	}
+
+	return &ObjNative{Name: name, Arity: arity, Function: function}, nil
+}
+
+func mapKey(key Value) (interface{}, error) {
+	if key.IsObj() && !key.IsString() {
+		return nil, errors.New("Map keys must be strings, numbers, booleans or nil.")
+	}
+	if key.IsNumber() && math.IsNaN(key.number) {
+		// NaN isn't equal to itself, so it could never be found again.
+		return nil, errors.New("Map keys can't be NaN.")
+	}
+
+	return key.Interface(), nil
+}
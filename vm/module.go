@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleLoader finds and compiles the files named by import statements.
+type ModuleLoader interface {
+	// Find returns the file that path refers to when it is imported from
+	// importer, which is empty for the main script.
+	Find(importer string, path string) (string, error)
+	// Load compiles the module in file into its top-level function.
+	Load(file string) (*ObjFunction, error)
+}
+
+// ObjModule is an imported file. Its top-level variables are exported as
+// properties, except for those whose names start with an underscore.
+type ObjModule struct {
+	Name    string
+	File    string
+	Globals map[string]Value
+}
+
+func NewModule(file string) *ObjModule {
+	return &ObjModule{
+		Name:    strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+		File:    file,
+		Globals: make(map[string]Value),
+	}
+}
+
+func (module *ObjModule) String() string {
+	return fmt.Sprintf("<module %s>", module.Name)
+}
+
+func moduleExport(module *ObjModule, name string) (Value, error) {
+	if !strings.HasPrefix(name, "_") {
+		if value, isPresent := module.Globals[name]; isPresent {
+			return value, nil
+		}
+	}
+
+	return Nil, fmt.Errorf("Module '%s' has no export '%s'.", module.Name, name)
+}
+
+// importModule pushes the module that path refers to. The first time a
+// module is imported its top-level code is called instead, and the module
+// takes the place of its result when that returns.
+func (vm *VM) importModule(path string) error {
+	if vm.loader == nil {
+		return vm.runtimeError("Imports are not supported here.")
+	}
+
+	file, err := vm.loader.Find(vm.frames[vm.frameCount-1].closure.Module.File, path)
+	if err != nil {
+		return vm.runtimeError("%s", err.Error())
+	}
+
+	if module, isLoaded := vm.modules[file]; isLoaded {
+		vm.push(ObjValue(module))
+		return nil
+	}
+
+	if cycle := vm.importCycle(file); cycle != nil {
+		return vm.runtimeError("Import cycle: %s.", strings.Join(cycle, " -> "))
+	}
+
+	function, err := vm.loader.Load(file)
+	if err != nil {
+		return vm.runtimeError("%s", err.Error())
+	}
+
+	module := NewModule(file)
+	closure := NewClosure(function)
+	closure.Module = module
+	vm.push(ObjValue(closure))
+	if err := vm.call(closure, 0); err != nil {
+		return err
+	}
+	vm.frames[vm.frameCount-1].module = module
+	return nil
+}
+
+// importCycle returns the chain of imports leading back to file if it is
+// already being imported, or nil if it isn't.
+func (vm *VM) importCycle(file string) []string {
+	var importing []string
+	for i := 0; i < vm.frameCount; i++ {
+		if module := vm.frames[i].module; module != nil {
+			importing = append(importing, module.File)
+		}
+	}
+
+	for i, importer := range importing {
+		if importer == file {
+			return append(importing[i:], file)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// Obj is any heap-allocated value. The Go garbage collector owns their
+// lifetime so unlike clox there is no object list or mark bit.
+type Obj interface {
+	String() string
+}
+
+type ObjString struct {
+	Chars string
+}
+
+func (str *ObjString) String() string {
+	return str.Chars
+}
+
+// ObjFunction is the compiled form of a function declaration. The top-level
+// script is also compiled into an ObjFunction with an empty name.
+type ObjFunction struct {
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        Chunk
+}
+
+func NewFunction(name string) *ObjFunction {
+	return &ObjFunction{Name: name}
+}
+
+func (function *ObjFunction) String() string {
+	if function.Name == "" {
+		return "<script>"
+	}
+
+	return fmt.Sprintf("<fn %s>", function.Name)
+}
+
+type NativeFn func(arguments []Value) (Value, error)
+
+type ObjNative struct {
+	Name     string
+	Arity    int
+	Variadic bool
+	Function NativeFn
+}
+
+func (native *ObjNative) String() string {
+	return "<native fn>"
+}
+
+// ObjUpvalue refers to a variable captured by a closure. While the variable
+// is still on the stack Location points into the stack; once the enclosing
+// function returns the value is copied into Closed and Location is
+// redirected to it.
+type ObjUpvalue struct {
+	Location *Value
+	Closed   Value
+	slot     int
+	next     *ObjUpvalue
+}
+
+func (upvalue *ObjUpvalue) String() string {
+	return "upvalue"
+}
+
+type ObjClosure struct {
+	Function *ObjFunction
+	Upvalues []*ObjUpvalue
+	// Module is the module the closure was created in, whose globals it
+	// reads and writes.
+	Module *ObjModule
+}
+
+func NewClosure(function *ObjFunction) *ObjClosure {
+	return &ObjClosure{
+		Function: function,
+		Upvalues: make([]*ObjUpvalue, function.UpvalueCount),
+	}
+}
+
+func (closure *ObjClosure) String() string {
+	return closure.Function.String()
+}
+
+type ObjClass struct {
+	Name        string
+	Methods     map[string]*ObjClosure
+	initializer *ObjClosure
+}
+
+func NewClass(name string) *ObjClass {
+	return &ObjClass{
+		Name:    name,
+		Methods: make(map[string]*ObjClosure),
+	}
+}
+
+func (class *ObjClass) String() string {
+	return class.Name
+}
+
+type ObjInstance struct {
+	Class  *ObjClass
+	Fields map[string]Value
+}
+
+func NewInstance(class *ObjClass) *ObjInstance {
+	return &ObjInstance{
+		Class:  class,
+		Fields: make(map[string]Value),
+	}
+}
+
+func (instance *ObjInstance) String() string {
+	return instance.Class.Name + " instance"
+}
+
+type ObjList struct {
+	Elements []Value
+}
+
+func NewList(elements []Value) *ObjList {
+	return &ObjList{Elements: elements}
+}
+
+func (list *ObjList) String() string {
+	return repr(ObjValue(list), make(map[Obj]bool))
+}
+
+type ObjBoundMethod struct {
+	Receiver Value
+	Method   *ObjClosure
+}
+
+func (method *ObjBoundMethod) String() string {
+	return method.Method.String()
+}
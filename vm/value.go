@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"strconv"
+
+	"github.com/jordanwebster/golox/native"
+)
+
+type ValueType byte
+
+const (
+	ValueNil ValueType = iota
+	ValueBool
+	ValueNumber
+	ValueObj
+)
+
+// Value is an unboxed Lox value. Booleans and numbers are stored inline so
+// that arithmetic never allocates; everything else lives behind an Obj.
+type Value struct {
+	Type   ValueType
+	number float64
+	obj    Obj
+}
+
+var Nil = Value{Type: ValueNil}
+
+func BoolValue(b bool) Value {
+	if b {
+		return Value{Type: ValueBool, number: 1}
+	}
+
+	return Value{Type: ValueBool, number: 0}
+}
+
+func NumberValue(n float64) Value {
+	return Value{Type: ValueNumber, number: n}
+}
+
+func ObjValue(obj Obj) Value {
+	return Value{Type: ValueObj, obj: obj}
+}
+
+func StringValue(s string) Value {
+	return ObjValue(&ObjString{Chars: s})
+}
+
+func (value Value) IsNil() bool {
+	return value.Type == ValueNil
+}
+
+func (value Value) IsBool() bool {
+	return value.Type == ValueBool
+}
+
+func (value Value) IsNumber() bool {
+	return value.Type == ValueNumber
+}
+
+func (value Value) IsObj() bool {
+	return value.Type == ValueObj
+}
+
+func (value Value) AsBool() bool {
+	return value.number != 0
+}
+
+func (value Value) AsNumber() float64 {
+	return value.number
+}
+
+func (value Value) AsObj() Obj {
+	return value.obj
+}
+
+func (value Value) IsString() bool {
+	_, isString := value.obj.(*ObjString)
+	return value.Type == ValueObj && isString
+}
+
+func (value Value) AsString() string {
+	return value.obj.(*ObjString).Chars
+}
+
+func (value Value) IsFalsey() bool {
+	return value.Type == ValueNil || (value.Type == ValueBool && !value.AsBool())
+}
+
+func (value Value) String() string {
+	switch value.Type {
+	case ValueNil:
+		return "nil"
+	case ValueBool:
+		return strconv.FormatBool(value.AsBool())
+	case ValueNumber:
+		return strconv.FormatFloat(value.number, 'f', -1, 64)
+	default:
+		return value.obj.String()
+	}
+}
+
+// Interface converts the value to the representation used by the
+// tree-walking interpreter and by native functions: nil, bool, float64 and
+// string, with any other object passed through unchanged.
+func (value Value) Interface() interface{} {
+	switch value.Type {
+	case ValueNil:
+		return nil
+	case ValueBool:
+		return value.AsBool()
+	case ValueNumber:
+		return value.number
+	default:
+		if str, isString := value.obj.(*ObjString); isString {
+			return str.Chars
+		}
+		return value.obj
+	}
+}
+
+// ValueOf is the inverse of Value.Interface. It reports false if the Go
+// value has no Lox representation.
+func ValueOf(v interface{}) (Value, bool) {
+	switch v := v.(type) {
+	case nil:
+		return Nil, true
+	case bool:
+		return BoolValue(v), true
+	case float64:
+		return NumberValue(v), true
+	case string:
+		return StringValue(v), true
+	case *ObjString, *ObjFunction, *ObjNative, *ObjClosure, *ObjClass, *ObjInstance,
+		*ObjBoundMethod, *ObjList, *ObjMap, *ObjModule, *ObjError, native.Object:
+		// Values a native was passed as interface{} and returned unchanged.
+		return ObjValue(v.(Obj)), true
+	case []interface{}:
+		// Natives return slices for lists.
+		elements := make([]Value, len(v))
+		for i, element := range v {
+			value, isValid := ValueOf(element)
+			if !isValid {
+				return Nil, false
+			}
+			elements[i] = value
+		}
+		return ObjValue(NewList(elements)), true
+	case *native.OrderedMap:
+		m := NewMap()
+		for _, entry := range v.Entries() {
+			key, isValidKey := ValueOf(entry.Key)
+			value, isValid := ValueOf(entry.Value)
+			if !isValidKey || !isValid || m.Set(key, value) != nil {
+				return Nil, false
+			}
+		}
+		return ObjValue(m), true
+	default:
+		return Nil, false
+	}
+}
+
+func valuesEqual(a Value, b Value) bool {
+	if a.Type != b.Type {
+		return false
+	}
+
+	switch a.Type {
+	case ValueNil:
+		return true
+	case ValueBool, ValueNumber:
+		return a.number == b.number
+	default:
+		aString, isAString := a.obj.(*ObjString)
+		bString, isBString := b.obj.(*ObjString)
+		if isAString && isBString {
+			return aString.Chars == bString.Chars
+		}
+
+		return a.obj == b.obj
+	}
+}
@@ -0,0 +1,775 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jordanwebster/golox/loxerror"
+	"github.com/jordanwebster/golox/native"
+	"github.com/jordanwebster/golox/token"
+)
+
+const (
+	framesMax = 1024
+	stackMax  = framesMax * 64
+	// A single frame can address at most this many slots, so a call is only
+	// allowed if there is room for all of them.
+	frameSlotsMax = 256
+)
+
+type callFrame struct {
+	closure *ObjClosure
+	ip      int
+	// Index of the first stack slot the frame can use. Slot zero holds the
+	// callee itself, or the receiver for methods.
+	slots int
+	// module is set if the frame runs the top-level code of an imported
+	// module.
+	module *ObjModule
+}
+
+type VM struct {
+	frames       [framesMax]callFrame
+	frameCount   int
+	stack        [stackMax]Value
+	stackTop     int
+	builtins     map[string]Value
+	main         *ObjModule
+	modules      map[string]*ObjModule
+	loader       ModuleLoader
+	openUpvalues *ObjUpvalue
+	handlers     []handler
+	stdout       io.Writer
+	stderr       io.Writer
+	diagnostics  *loxerror.Diagnostics
+	ctx          context.Context
+	// While a native calls back into Lox, execution stops once the frame
+	// count drops back to baseFrame and only handlers above baseHandler can
+	// catch errors.
+	baseFrame   int
+	baseHandler int
+}
+
+// NewVM creates a VM that writes the output of print and write to stdout
+// and that of eprint to stderr.
+func NewVM(stdout io.Writer, stderr io.Writer, diagnostics *loxerror.Diagnostics) *VM {
+	vm := &VM{
+		builtins:    make(map[string]Value),
+		main:        NewModule(""),
+		modules:     make(map[string]*ObjModule),
+		stdout:      stdout,
+		stderr:      stderr,
+		diagnostics: diagnostics,
+		ctx:         context.Background(),
+	}
+
+	vm.DefineNative("clock", 0, func(arguments []Value) (Value, error) {
+		return NumberValue(float64(time.Now().UnixNano()) / float64(time.Second)), nil
+	})
+	vm.DefineNative("eprint", 1, func(arguments []Value) (Value, error) {
+		fmt.Fprintln(vm.stderr, arguments[0].String())
+		return Nil, nil
+	})
+	vm.DefineNative("write", 1, func(arguments []Value) (Value, error) {
+		fmt.Fprint(vm.stdout, arguments[0].String())
+		return Nil, nil
+	})
+	for _, function := range native.MathFunctions() {
+		vm.DefineGoFunction(function)
+	}
+	for name, value := range native.MathConstants {
+		vm.builtins[name] = NumberValue(value)
+	}
+	json := native.JSON()
+	vm.builtins[json.Name()] = ObjValue(json)
+
+	return vm
+}
+
+// DefineNative makes a Go function callable from Lox as a global.
+func (vm *VM) DefineNative(name string, arity int, function NativeFn) {
+	vm.builtins[name] = ObjValue(&ObjNative{Name: name, Arity: arity, Function: function})
+}
+
+// DefineGoFunction exposes a Go function wrapped by the native package,
+// converting values on the way in and out.
+func (vm *VM) DefineGoFunction(function *native.Function) {
+	vm.builtins[function.Name()] = ObjValue(goFunction(function))
+}
+
+// DefineFileSystem defines the fs global, which gives scripts access to
+// files.
+func (vm *VM) DefineFileSystem() {
+	fs := native.FileSystem()
+	vm.builtins[fs.Name()] = ObjValue(fs)
+}
+
+// DefineTestNatives defines the assertions available to test scripts.
+func (vm *VM) DefineTestNatives() {
+	for _, function := range native.AssertFunctions() {
+		vm.DefineGoFunction(function)
+	}
+	vm.DefineNative("assertThrows", 1, vm.assertThrows)
+}
+
+// assertThrows calls a function that takes no arguments and fails unless it
+// throws, returning what was thrown so that tests can inspect it.
+func (vm *VM) assertThrows(arguments []Value) (Value, error) {
+	var arity int
+	switch function := arguments[0].obj.(type) {
+	case *ObjClosure:
+		arity = function.Function.Arity
+	case *ObjBoundMethod:
+		arity = function.Method.Function.Arity
+	default:
+		return Nil, errors.New("Argument to 'assertThrows' must be a function.")
+	}
+	if arity != 0 {
+		return Nil, errors.New("Function passed to 'assertThrows' must not take arguments.")
+	}
+
+	_, err := vm.callNested(arguments[0])
+	switch err := err.(type) {
+	case nil:
+		return Nil, errors.New("Expected function to throw.")
+	case *exception:
+		return err.value, nil
+	case *loxerror.RuntimeError:
+		return ObjValue(&ObjError{err: err}), nil
+	}
+	return Nil, err
+}
+
+func goFunction(function *native.Function) *ObjNative {
+	return &ObjNative{
+		Name:     function.Name(),
+		Arity:    function.Arity(),
+		Variadic: function.IsVariadic(),
+		Function: func(arguments []Value) (Value, error) {
+			converted := make([]interface{}, len(arguments))
+			for i, argument := range arguments {
+				converted[i] = argument.Interface()
+			}
+
+			result, err := function.Call(converted)
+			if err != nil {
+				return Nil, err
+			}
+
+			value, isValid := ValueOf(result)
+			if !isValid {
+				return Nil, fmt.Errorf("Native function '%s' returned an unsupported %T.", function.Name(), result)
+			}
+			return value, nil
+		},
+	}
+}
+
+// SetModuleLoader sets how import statements find their modules. Without
+// one, imports fail.
+func (vm *VM) SetModuleLoader(loader ModuleLoader) {
+	vm.loader = loader
+}
+
+// Interpret runs a compiled top-level script. Globals persist between calls
+// so that the REPL can run one statement at a time. The context is checked
+// on every backward jump and call so that runaway scripts can be cancelled.
+func (vm *VM) Interpret(ctx context.Context, function *ObjFunction) error {
+	vm.ctx = ctx
+	defer func() {
+		vm.ctx = context.Background()
+	}()
+
+	closure := NewClosure(function)
+	closure.Module = vm.main
+	vm.push(ObjValue(closure))
+	if err := vm.call(closure, 0); err != nil {
+		return vm.abort(err)
+	}
+
+	return vm.run(ctx)
+}
+
+// run executes until the script returns. Each time an error is raised
+// inside a try block execution resumes at its handler.
+func (vm *VM) run(ctx context.Context) error {
+	for {
+		err := vm.execute(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if !vm.catch(err) {
+			return vm.abort(err)
+		}
+	}
+}
+
+// callNested calls a function from a native and runs it to completion,
+// leaving the stack as it was. Errors the function doesn't catch itself are
+// returned rather than reported.
+func (vm *VM) callNested(callee Value) (Value, error) {
+	frameCount, stackTop, handlerCount := vm.frameCount, vm.stackTop, len(vm.handlers)
+	baseFrame, baseHandler := vm.baseFrame, vm.baseHandler
+	vm.baseFrame, vm.baseHandler = frameCount, handlerCount
+	defer func() {
+		vm.baseFrame, vm.baseHandler = baseFrame, baseHandler
+	}()
+
+	vm.push(callee)
+	err := vm.callValue(callee, 0)
+	// Natives return immediately but closures leave a frame to run.
+	for err == nil && vm.frameCount > frameCount {
+		if err = vm.execute(vm.ctx); err != nil && vm.catch(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		vm.closeUpvalues(stackTop)
+		vm.frameCount = frameCount
+		vm.stackTop = stackTop
+		vm.handlers = vm.handlers[:handlerCount]
+		return Nil, err
+	}
+
+	return vm.pop(), nil
+}
+
+// abort reports an uncaught error and abandons the script.
+func (vm *VM) abort(err error) error {
+	if thrown, isException := err.(*exception); isException {
+		err = thrown.err
+	}
+	if _, isRuntimeError := err.(*loxerror.RuntimeError); isRuntimeError {
+		vm.diagnostics.Report(err)
+	}
+
+	vm.resetStack()
+	return err
+}
+
+func (vm *VM) execute(ctx context.Context) error {
+	done := ctx.Done()
+	frame := &vm.frames[vm.frameCount-1]
+	code := frame.closure.Function.Chunk.Code
+	constants := frame.closure.Function.Chunk.Constants
+
+	readByte := func() byte {
+		b := code[frame.ip]
+		frame.ip++
+		return b
+	}
+	readShort := func() int {
+		frame.ip += 2
+		return int(code[frame.ip-2])<<8 | int(code[frame.ip-1])
+	}
+	readString := func() string {
+		return constants[readShort()].AsString()
+	}
+	// Calls and returns switch frames so the cached chunk must be reloaded.
+	loadFrame := func() {
+		frame = &vm.frames[vm.frameCount-1]
+		code = frame.closure.Function.Chunk.Code
+		constants = frame.closure.Function.Chunk.Constants
+	}
+	// A nil done channel is never ready so contexts that can't be cancelled
+	// cost nothing beyond the select.
+	cancelled := func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+
+	for {
+		switch instruction := OpCode(readByte()); instruction {
+		case OpConstant:
+			vm.push(constants[readShort()])
+		case OpNil:
+			vm.push(Nil)
+		case OpTrue:
+			vm.push(BoolValue(true))
+		case OpFalse:
+			vm.push(BoolValue(false))
+		case OpPop:
+			vm.stackTop--
+		case OpGetLocal:
+			vm.push(vm.stack[frame.slots+int(readByte())])
+		case OpSetLocal:
+			vm.stack[frame.slots+int(readByte())] = vm.peek(0)
+		case OpGetGlobal:
+			name := readString()
+			value, isPresent := frame.closure.Module.Globals[name]
+			if !isPresent {
+				value, isPresent = vm.builtins[name]
+			}
+			if !isPresent {
+				return vm.undefinedVariable(name)
+			}
+			vm.push(value)
+		case OpDefineGlobal:
+			frame.closure.Module.Globals[readString()] = vm.pop()
+		case OpSetGlobal:
+			name := readString()
+			globals := frame.closure.Module.Globals
+			if _, isPresent := globals[name]; !isPresent {
+				globals = vm.builtins
+			}
+			if _, isPresent := globals[name]; !isPresent {
+				return vm.undefinedVariable(name)
+			}
+			globals[name] = vm.peek(0)
+		case OpGetUpvalue:
+			vm.push(*frame.closure.Upvalues[readByte()].Location)
+		case OpSetUpvalue:
+			*frame.closure.Upvalues[readByte()].Location = vm.peek(0)
+		case OpGetProperty:
+			name := readString()
+			if list, isList := vm.peek(0).obj.(*ObjList); isList {
+				method, err := listMethod(list, name)
+				if err != nil {
+					return vm.runtimeError("%s", err.Error())
+				}
+				vm.stack[vm.stackTop-1] = ObjValue(method)
+				break
+			}
+			if vm.peek(0).IsString() {
+				method, isPresent := native.StringMethod(vm.peek(0).AsString(), name)
+				if !isPresent {
+					return vm.runtimeError("Undefined property '%s'.", name)
+				}
+				vm.stack[vm.stackTop-1] = ObjValue(goFunction(method))
+				break
+			}
+			if m, isMap := vm.peek(0).obj.(*ObjMap); isMap {
+				method, err := mapMethod(m, name)
+				if err != nil {
+					return vm.runtimeError("%s", err.Error())
+				}
+				vm.stack[vm.stackTop-1] = ObjValue(method)
+				break
+			}
+			if module, isModule := vm.peek(0).obj.(*ObjModule); isModule {
+				value, err := moduleExport(module, name)
+				if err != nil {
+					return vm.runtimeError("%s", err.Error())
+				}
+				vm.stack[vm.stackTop-1] = value
+				break
+			}
+			if object, isObject := vm.peek(0).obj.(native.Object); isObject {
+				method, isPresent := object.Method(name)
+				if !isPresent {
+					return vm.runtimeError("Undefined property '%s'.", name)
+				}
+				vm.stack[vm.stackTop-1] = ObjValue(goFunction(method))
+				break
+			}
+			if e, isError := vm.peek(0).obj.(*ObjError); isError {
+				value, err := errorProperty(e, name)
+				if err != nil {
+					return vm.runtimeError("%s", err.Error())
+				}
+				vm.stack[vm.stackTop-1] = value
+				break
+			}
+
+			instance, isInstance := vm.peek(0).obj.(*ObjInstance)
+			if !isInstance {
+				return vm.runtimeError("Only instances have properties.")
+			}
+
+			if value, isPresent := instance.Fields[name]; isPresent {
+				vm.stack[vm.stackTop-1] = value
+				break
+			}
+
+			if err := vm.bindMethod(instance.Class, name); err != nil {
+				return err
+			}
+		case OpSetProperty:
+			instance, isInstance := vm.peek(1).obj.(*ObjInstance)
+			if !isInstance {
+				return vm.runtimeError("Only instances have fields.")
+			}
+
+			instance.Fields[readString()] = vm.peek(0)
+			value := vm.pop()
+			vm.stack[vm.stackTop-1] = value
+		case OpGetSuper:
+			name := readString()
+			superclass := vm.pop().obj.(*ObjClass)
+			if err := vm.bindMethod(superclass, name); err != nil {
+				return err
+			}
+		case OpBuildList:
+			count := readShort()
+			elements := make([]Value, count)
+			copy(elements, vm.stack[vm.stackTop-count:vm.stackTop])
+			vm.stackTop -= count
+			vm.push(ObjValue(NewList(elements)))
+		case OpBuildMap:
+			count := readShort()
+			m := NewMap()
+			for i := vm.stackTop - 2*count; i < vm.stackTop; i += 2 {
+				if err := m.Set(vm.stack[i], vm.stack[i+1]); err != nil {
+					return vm.runtimeError("%s", err.Error())
+				}
+			}
+			vm.stackTop -= 2 * count
+			vm.push(ObjValue(m))
+		case OpInterpolate:
+			count := readShort()
+			var builder strings.Builder
+			for _, part := range vm.stack[vm.stackTop-count : vm.stackTop] {
+				builder.WriteString(part.String())
+			}
+			vm.stackTop -= count
+			vm.push(StringValue(builder.String()))
+		case OpGetIndex:
+			value, err := getIndex(vm.peek(1), vm.peek(0))
+			if err != nil {
+				return vm.runtimeError("%s", err.Error())
+			}
+			vm.stackTop--
+			vm.stack[vm.stackTop-1] = value
+		case OpSetIndex:
+			value := vm.peek(0)
+			if err := setIndex(vm.peek(2), vm.peek(1), value); err != nil {
+				return vm.runtimeError("%s", err.Error())
+			}
+			vm.stackTop -= 2
+			vm.stack[vm.stackTop-1] = value
+		case OpSlice:
+			list, isList := vm.peek(2).obj.(*ObjList)
+			if !isList {
+				return vm.runtimeError("Only lists can be sliced.")
+			}
+
+			slice, err := sliceList(list, vm.peek(1), vm.peek(0))
+			if err != nil {
+				return vm.runtimeError("%s", err.Error())
+			}
+			vm.stackTop -= 2
+			vm.stack[vm.stackTop-1] = ObjValue(slice)
+		case OpEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(BoolValue(valuesEqual(a, b)))
+		case OpNotEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(BoolValue(!valuesEqual(a, b)))
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpSubtract, OpMultiply, OpDivide:
+			if !vm.peek(0).IsNumber() || !vm.peek(1).IsNumber() {
+				return vm.runtimeError("Operands must be numbers.")
+			}
+			b := vm.pop().number
+			a := vm.pop().number
+			vm.push(binaryNumberOp(instruction, a, b))
+		case OpAdd:
+			if vm.peek(0).IsNumber() && vm.peek(1).IsNumber() {
+				b := vm.pop().number
+				a := vm.pop().number
+				vm.push(NumberValue(a + b))
+			} else if vm.peek(0).IsString() && vm.peek(1).IsString() {
+				b := vm.pop().AsString()
+				a := vm.pop().AsString()
+				vm.push(StringValue(a + b))
+			} else {
+				return vm.runtimeError("Operands must be two numbers or two strings.")
+			}
+		case OpNot:
+			vm.push(BoolValue(vm.pop().IsFalsey()))
+		case OpNegate:
+			if !vm.peek(0).IsNumber() {
+				return vm.runtimeError("Operand must be a number.")
+			}
+			vm.push(NumberValue(-vm.pop().number))
+		case OpPrint:
+			fmt.Fprintln(vm.stdout, vm.pop().String())
+		case OpJump:
+			offset := readShort()
+			frame.ip += offset
+		case OpJumpIfFalse:
+			offset := readShort()
+			if vm.peek(0).IsFalsey() {
+				frame.ip += offset
+			}
+		case OpLoop:
+			offset := readShort()
+			frame.ip -= offset
+			if cancelled() {
+				return ctx.Err()
+			}
+		case OpCall:
+			argCount := int(readByte())
+			if cancelled() {
+				return ctx.Err()
+			}
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return err
+			}
+			loadFrame()
+		case OpClosure:
+			function := constants[readShort()].obj.(*ObjFunction)
+			closure := NewClosure(function)
+			closure.Module = frame.closure.Module
+			vm.push(ObjValue(closure))
+			for i := range closure.Upvalues {
+				isLocal := readByte()
+				index := int(readByte())
+				if isLocal == 1 {
+					closure.Upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.Upvalues[i] = frame.closure.Upvalues[index]
+				}
+			}
+		case OpCloseUpvalue:
+			vm.closeUpvalues(vm.stackTop - 1)
+			vm.stackTop--
+		case OpReturn:
+			result := vm.pop()
+			if frame.module != nil {
+				vm.modules[frame.module.File] = frame.module
+				result = ObjValue(frame.module)
+			}
+			vm.closeUpvalues(frame.slots)
+			vm.frameCount--
+			if vm.frameCount == 0 {
+				vm.stackTop--
+				return nil
+			}
+
+			vm.stackTop = frame.slots
+			vm.push(result)
+			if vm.frameCount == vm.baseFrame {
+				return nil
+			}
+			loadFrame()
+		case OpThrow:
+			return vm.throw(vm.pop())
+		case OpTry, OpTryFinally:
+			offset := readShort()
+			vm.handlers = append(vm.handlers, handler{
+				frameCount: vm.frameCount,
+				stackTop:   vm.stackTop,
+				ip:         frame.ip + offset,
+				finally:    instruction == OpTryFinally,
+			})
+		case OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case OpClass:
+			vm.push(ObjValue(NewClass(readString())))
+		case OpInherit:
+			superclass, isClass := vm.peek(1).obj.(*ObjClass)
+			if !isClass {
+				return vm.runtimeError("Superclass must be a class.")
+			}
+
+			// Classes can't be modified once declared so copying the methods
+			// down is equivalent to walking the superclass chain on lookup.
+			subclass := vm.peek(0).obj.(*ObjClass)
+			for name, method := range superclass.Methods {
+				subclass.Methods[name] = method
+			}
+			subclass.initializer = superclass.initializer
+			vm.stackTop--
+		case OpImport:
+			if err := vm.importModule(readString()); err != nil {
+				return err
+			}
+			loadFrame()
+		case OpMethod:
+			name := readString()
+			method := vm.peek(0).obj.(*ObjClosure)
+			class := vm.peek(1).obj.(*ObjClass)
+			class.Methods[name] = method
+			if name == "init" {
+				class.initializer = method
+			}
+			vm.stackTop--
+		default:
+			return vm.runtimeError("Unknown opcode %d.", instruction)
+		}
+	}
+}
+
+func binaryNumberOp(instruction OpCode, a float64, b float64) Value {
+	switch instruction {
+	case OpGreater:
+		return BoolValue(a > b)
+	case OpGreaterEqual:
+		return BoolValue(a >= b)
+	case OpLess:
+		return BoolValue(a < b)
+	case OpLessEqual:
+		return BoolValue(a <= b)
+	case OpSubtract:
+		return NumberValue(a - b)
+	case OpMultiply:
+		return NumberValue(a * b)
+	default:
+		return NumberValue(a / b)
+	}
+}
+
+func (vm *VM) callValue(callee Value, argCount int) error {
+	switch callee := callee.obj.(type) {
+	case *ObjBoundMethod:
+		vm.stack[vm.stackTop-argCount-1] = callee.Receiver
+		return vm.call(callee.Method, argCount)
+	case *ObjClass:
+		vm.stack[vm.stackTop-argCount-1] = ObjValue(NewInstance(callee))
+		if callee.initializer != nil {
+			return vm.call(callee.initializer, argCount)
+		} else if argCount != 0 {
+			return vm.runtimeError("Expected 0 arguments but got %d.", argCount)
+		}
+		return nil
+	case *ObjClosure:
+		return vm.call(callee, argCount)
+	case *ObjNative:
+		if callee.Variadic && argCount < callee.Arity {
+			return vm.runtimeError("Expected at least %d arguments but got %d.", callee.Arity, argCount)
+		} else if !callee.Variadic && argCount != callee.Arity {
+			return vm.runtimeError("Expected %d arguments but got %d.", callee.Arity, argCount)
+		}
+
+		result, err := callee.Function(vm.stack[vm.stackTop-argCount : vm.stackTop])
+		if err != nil {
+			return vm.runtimeError("%s", err.Error())
+		}
+		vm.stackTop -= argCount + 1
+		vm.push(result)
+		return nil
+	}
+
+	return vm.runtimeError("Can only call functions and classes.")
+}
+
+func (vm *VM) call(closure *ObjClosure, argCount int) error {
+	if argCount != closure.Function.Arity {
+		return vm.runtimeError("Expected %d arguments but got %d.", closure.Function.Arity, argCount)
+	}
+
+	if vm.frameCount == framesMax || vm.stackTop+frameSlotsMax > stackMax {
+		return vm.runtimeError("Stack overflow.")
+	}
+
+	frame := &vm.frames[vm.frameCount]
+	vm.frameCount++
+	frame.closure = closure
+	frame.ip = 0
+	frame.slots = vm.stackTop - argCount - 1
+	frame.module = nil
+	return nil
+}
+
+func (vm *VM) bindMethod(class *ObjClass, name string) error {
+	method, isPresent := class.Methods[name]
+	if !isPresent {
+		return vm.runtimeError("Undefined property '%s'.", name)
+	}
+
+	bound := &ObjBoundMethod{Receiver: vm.peek(0), Method: method}
+	vm.stack[vm.stackTop-1] = ObjValue(bound)
+	return nil
+}
+
+// captureUpvalue reuses an existing upvalue for the slot if another closure
+// has already captured it so that both closures see the same variable.
+func (vm *VM) captureUpvalue(slot int) *ObjUpvalue {
+	var prev *ObjUpvalue = nil
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		prev = upvalue
+		upvalue = upvalue.next
+	}
+
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &ObjUpvalue{Location: &vm.stack[slot], slot: slot, next: upvalue}
+	if prev == nil {
+		vm.openUpvalues = created
+	} else {
+		prev.next = created
+	}
+
+	return created
+}
+
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.Closed = *upvalue.Location
+		upvalue.Location = &upvalue.Closed
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+// runtimeError creates an error at the current instruction. It is only
+// reported if it goes uncaught.
+func (vm *VM) runtimeError(format string, args ...interface{}) *loxerror.RuntimeError {
+	frame := &vm.frames[vm.frameCount-1]
+	chunk := &frame.closure.Function.Chunk
+	position := token.Token{Line: chunk.Line(frame.ip - 1), Span: chunk.Span(frame.ip - 1)}
+	err := loxerror.NewRuntimeError(position, fmt.Sprintf(format, args...))
+	err.WithTrace(vm.stackTrace())
+	return err
+}
+
+func (vm *VM) undefinedVariable(name string) *loxerror.RuntimeError {
+	help := fmt.Sprintf("declare it with 'var %s;' before using it", name)
+	return vm.runtimeError("Undefined variable '%s'.", name).WithHelp(help)
+}
+
+func (vm *VM) stackTrace() []loxerror.Frame {
+	trace := make([]loxerror.Frame, 0, vm.frameCount)
+	for i := vm.frameCount - 1; i >= 0; i-- {
+		frame := &vm.frames[i]
+		function := frame.closure.Function
+		name := function.Name
+		if frame.module != nil {
+			name = "<module>"
+		} else if name == "" {
+			name = "<script>"
+		}
+		trace = append(trace, loxerror.Frame{
+			Function: name,
+			File:     frame.closure.Module.File,
+			Line:     function.Chunk.Line(frame.ip - 1),
+		})
+	}
+
+	return trace
+}
+
+func (vm *VM) resetStack() {
+	vm.stackTop = 0
+	vm.frameCount = 0
+	vm.openUpvalues = nil
+	vm.handlers = nil
+}
+
+func (vm *VM) push(value Value) {
+	vm.stack[vm.stackTop] = value
+	vm.stackTop++
+}
+
+func (vm *VM) pop() Value {
+	vm.stackTop--
+	return vm.stack[vm.stackTop]
+}
+
+func (vm *VM) peek(distance int) Value {
+	return vm.stack[vm.stackTop-1-distance]
+}